@@ -5,6 +5,7 @@ import (
 	"log"
 	"reflect"
 	"regexp"
+	"time"
 )
 
 type Weekday uint8
@@ -19,6 +20,19 @@ const (
 	DateSunday
 )
 
+// ISO-8601 calendar date layout used by the planning APIs
+const DateLayout = "2006-01-02"
+
+// ParseDate parses an ISO-8601 calendar date as the midnight of the date in the location
+func ParseDate(date string, location *time.Location) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, date, location)
+}
+
+// GetWeekday converts the weekday of a date to Weekday, in which Monday is the first day of a week
+func GetWeekday(date time.Time) Weekday {
+	return Weekday((int(date.Weekday()) + 6) % 7)
+}
+
 type PlacePhoto struct {
 	// reference from Google Images
 	Reference string `bson:"reference"`
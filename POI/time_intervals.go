@@ -2,17 +2,14 @@ package POI
 
 import (
 	"errors"
-	"github.com/weihesdlegend/Vacation-planner/utils"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
-type Hour uint8
-
 type TimeInterval struct {
-	Start Hour
-	End   Hour
+	Start TimeOfDay `json:"start"`
+	End   TimeOfDay `json:"end"`
 }
 
 type ByStartTime []TimeInterval
@@ -87,43 +84,52 @@ func (timeIntervals *GoogleMapsTimeIntervals) NumIntervals() int {
 	return timeIntervals.numIntervals
 }
 
-// given a string of form "Monday: 10:45 PM - 11:78 AM", return a TimeInterval with start and end hour in [0-24]
+var openingHourRegexp = regexp.MustCompile(`(\d{1,2}):(\d{2})[\s\x{202f}\x{2009}]*([apAP][mM])?`)
+
+// given a string of form "Monday: 10:45 AM – 5:30 PM", return a TimeInterval of the first opening period
+// "Closed" results in an empty interval at the start of the day and "Open 24 hours" results in the whole day
 func ParseTimeInterval(openingHour string) (interval TimeInterval, err error) {
-	closed, _ := regexp.Match(`Closed`, []byte(openingHour))
-	if closed {
-		interval.Start = 255
-		interval.End = 255
+	if strings.Contains(openingHour, "Closed") {
+		return
+	}
+	if strings.Contains(openingHour, "Open 24 hours") {
+		interval.End = EndOfDay
 		return
 	}
-	hour_re := regexp.MustCompile(`[\d]{1,2}:[\d]{2}`)
-	hours := hour_re.FindAll([]byte(openingHour), -1)
-
-	am_pm_re := regexp.MustCompile(`[apAP][mM]`)
-	am_pm := am_pm_re.FindAll([]byte(openingHour), -1)
 
-	if len(hours) < 2 || len(am_pm) < 2 {
+	times := openingHourRegexp.FindAllStringSubmatch(openingHour, -1)
+	if len(times) < 2 || times[1][3] == "" {
 		return TimeInterval{}, errors.New("cannot parse opening hour")
 	}
+	// the AM/PM designator of the start time can be omitted if it is the same as the end time, e.g. "5:00 – 10:00 PM"
+	if times[0][3] == "" {
+		times[0][3] = times[1][3]
+	}
 
-	interval.Start = Hour(calculateHour(string(hours[0]), string(am_pm[0])))
-	interval.End = Hour(calculateHour(string(hours[1]), string(am_pm[1])))
-
-	if interval.Start > interval.End { // late night hours
-		interval.End = 24
+	if interval.Start, err = parseClockTime(times[0][1], times[0][2], times[0][3]); err != nil {
+		return TimeInterval{}, err
+	}
+	if interval.End, err = parseClockTime(times[1][1], times[1][2], times[1][3]); err != nil {
+		return TimeInterval{}, err
 	}
 
+	if interval.Start >= interval.End { // late night hours
+		interval.End = EndOfDay
+	}
 	return
 }
 
-func calculateHour(time string, am_pm string) uint8 {
-	t := strings.Split(time, ":")
-	hour, err := strconv.ParseUint(t[0], 10, 8)
-	utils.CheckErrImmediate(err, utils.LogError)
+// convert 12-hour clock time to time of day, 12 AM is midnight and 12 PM is noon
+func parseClockTime(hourStr string, minuteStr string, amPm string) (TimeOfDay, error) {
+	hour, _ := strconv.Atoi(hourStr)
+	minute, _ := strconv.Atoi(minuteStr)
+	if hour < 1 || hour > 12 || minute >= MinutesPerHour {
+		return 0, errors.New("cannot parse opening hour")
+	}
 
-	if am_pm == "AM" || am_pm == "am" {
-		return uint8(hour)
-	} else if am_pm == "PM" || am_pm == "pm" {
-		return uint8(hour) + 12
+	hour %= 12
+	if strings.ToUpper(amPm) == "PM" {
+		hour += 12
 	}
-	return 255 // err
+	return NewTimeOfDay(hour, minute), nil
 }
@@ -0,0 +1,84 @@
+package POI
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const (
+	MinutesPerHour = 60
+	MinutesPerDay  = 24 * MinutesPerHour
+)
+
+// time of a day with minute resolution, number of minutes since midnight in [0, 1440]
+// 1440 is the end of the day, i.e. 24:00
+type TimeOfDay uint16
+
+const EndOfDay TimeOfDay = MinutesPerDay
+
+var timeOfDayRegexp = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?$`)
+
+func NewTimeOfDay(hour int, minute int) TimeOfDay {
+	return TimeOfDay(hour*MinutesPerHour + minute)
+}
+
+func (t TimeOfDay) Hour() int {
+	return int(t) / MinutesPerHour
+}
+
+func (t TimeOfDay) Minute() int {
+	return int(t) % MinutesPerHour
+}
+
+// format time of day as HH:MM
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour(), t.Minute())
+}
+
+// ParseTimeOfDay parses time of day in the format of HH:MM, or H for a whole hour
+func ParseTimeOfDay(s string) (t TimeOfDay, err error) {
+	matches := timeOfDayRegexp.FindStringSubmatch(s)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid time of day %s, expected format is HH:MM", s)
+	}
+	hour, _ := strconv.Atoi(matches[1])
+	minute := 0
+	if matches[2] != "" {
+		minute, _ = strconv.Atoi(matches[2])
+	}
+	if minute >= MinutesPerHour || NewTimeOfDay(hour, minute) > EndOfDay {
+		return 0, fmt.Errorf("invalid time of day %s", s)
+	}
+	return NewTimeOfDay(hour, minute), nil
+}
+
+// time of day is represented as "HH:MM" in JSON
+func (t TimeOfDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// accepts "HH:MM" strings, and integers as whole hours for compatibility with the hour-based API
+func (t *TimeOfDay) UnmarshalJSON(data []byte) error {
+	var hour uint
+	if err := json.Unmarshal(data, &hour); err == nil {
+		if hour > 24 {
+			return errors.New("invalid hour, valid hours are chosen from 0-24")
+		}
+		*t = NewTimeOfDay(int(hour), 0)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New("time of day must be a string in the format of HH:MM")
+	}
+	parsed, err := ParseTimeOfDay(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
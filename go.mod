@@ -1,6 +1,6 @@
 module github.com/weihesdlegend/Vacation-planner
 
-go 1.12
+go 1.20
 
 require (
 	github.com/GeertJohan/go.rice v1.0.0
@@ -11,29 +11,50 @@ require (
 	github.com/gin-gonic/gin v1.6.3
 	github.com/globalsign/mgo v0.0.0-20181015135952-eeefdecb41b8
 	github.com/go-playground/assert/v2 v2.0.1
-	github.com/go-playground/validator/v10 v10.3.0 // indirect
 	github.com/go-redis/redis/v7 v7.0.0-beta.4
+	github.com/gorilla/mux v1.7.3
+	github.com/kelseyhightower/envconfig v1.4.0
+	github.com/mpraski/clusters v0.0.0-20170921103932-51821d83008d
+	github.com/prometheus/client_golang v1.7.0
+	github.com/sirupsen/logrus v1.4.2
+	github.com/stretchr/testify v1.4.0
+	go.uber.org/zap v1.13.0
+	golang.org/x/crypto v0.0.0-20191219195013-becbf705a915
+	gonum.org/v1/gonum v0.0.0-20190808205415-ced62fe5104b
+	googlemaps.github.io/maps v0.0.0-20190731233030-3b2ef8dcfc73
+	gopkg.in/yaml.v2 v2.3.0
+)
+
+require (
+	github.com/alicebob/gopher-json v0.0.0-20180125190556-5a6b3ba71ee6 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.1.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gin-contrib/sse v0.1.0 // indirect
+	github.com/go-playground/locales v0.13.0 // indirect
+	github.com/go-playground/universal-translator v0.17.0 // indirect
+	github.com/go-playground/validator/v10 v10.3.0 // indirect
 	github.com/golang/protobuf v1.4.2 // indirect
 	github.com/gomodule/redigo v2.0.0+incompatible // indirect
 	github.com/google/uuid v1.1.1 // indirect
-	github.com/gorilla/mux v1.7.3
 	github.com/json-iterator/go v1.1.10 // indirect
-	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
+	github.com/leodido/go-urn v1.2.0 // indirect
+	github.com/mattn/go-isatty v0.0.12 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.1 // indirect
-	github.com/mpraski/clusters v0.0.0-20170921103932-51821d83008d
 	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/client_model v0.2.0 // indirect
+	github.com/prometheus/common v0.10.0 // indirect
+	github.com/prometheus/procfs v0.1.3 // indirect
 	github.com/sergi/go-diff v1.0.0 // indirect
-	github.com/sirupsen/logrus v1.4.2
-	github.com/stretchr/testify v1.4.0
+	github.com/ugorji/go/codec v1.1.7 // indirect
 	github.com/yuin/gopher-lua v0.0.0-20190514113301-1cd887cd7036 // indirect
-	go.uber.org/zap v1.13.0
-	golang.org/x/crypto v0.0.0-20191219195013-becbf705a915
+	go.uber.org/atomic v1.5.0 // indirect
+	go.uber.org/multierr v1.3.0 // indirect
 	golang.org/x/sys v0.0.0-20200728102440-3e129f6d46b1 // indirect
 	golang.org/x/time v0.0.0-20190308202827-9d24e82272b4 // indirect
-	gonum.org/v1/gonum v0.0.0-20190808205415-ced62fe5104b
 	google.golang.org/protobuf v1.25.0 // indirect
-	googlemaps.github.io/maps v0.0.0-20190731233030-3b2ef8dcfc73
-	gopkg.in/yaml.v2 v2.3.0 // indirect
 )
@@ -12,7 +12,7 @@ import (
 )
 
 const (
-	TimeClusterMinResults = 20
+	TimeClusterMinResults = 20 // default minimum number of places found by nearby search
 )
 
 type ClusterManager interface {
@@ -57,14 +57,17 @@ type TimeClustersManager struct {
 	places       []POI.Place
 	PlaceCat     POI.PlaceCategory
 	Weekday      POI.Weekday
+	// Google Maps is searched if fewer places are found in Redis
+	MinNumResults uint
 }
 
 // TimeClusterManager initialization
 func (placeManager *TimeClustersManager) Init(poiSearcher *iowrappers.PoiSearcher, placeCat POI.PlaceCategory,
-	timeIntervals []POI.TimeInterval, day POI.Weekday) {
+	timeIntervals []POI.TimeInterval, day POI.Weekday, minNumResults uint) {
 	placeManager.poiSearcher = poiSearcher
 	placeManager.PlaceCat = placeCat
 	placeManager.Weekday = day
+	placeManager.MinNumResults = minNumResults
 	placeManager.TimeClusters = &TimeClusters{Clusters: make(map[string]*TimeCluster, 0)}
 	placeManager.TimeClusters.TimeIntervals = &POI.GoogleMapsTimeIntervals{}
 	for _, interval := range timeIntervals {
@@ -82,13 +85,18 @@ func (placeManager *TimeClustersManager) PlaceSearch(location string, searchRadi
 		PlaceCat:      placeManager.PlaceCat,
 		Radius:        searchRadius,
 		RankBy:        "prominence",
-		MinNumResults: TimeClusterMinResults,
+		MinNumResults: placeManager.MinNumResults,
 	}
 	request.MaxNumResults = 2 * request.MinNumResults
 	placeManager.places, _ = placeManager.poiSearcher.NearbySearch(&request)
 	updatePlacesDetails(placeManager.poiSearcher, placeManager.places)
 }
 
+// number of Places found by PlaceSearch
+func (placeManager *TimeClustersManager) NumPlaces() int {
+	return len(placeManager.places)
+}
+
 // assign Places to time Clusters using their time interval info
 func (placeManager *TimeClustersManager) Clustering(day POI.Weekday) {
 	for _, place := range placeManager.places {
@@ -0,0 +1,169 @@
+package iowrappers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v7"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	APIKeyPrefix         = "vpk"
+	APIKeyKeyPrefix      = "api_key"
+	UserAPIKeysKeyPrefix = "api_keys"
+	MaxAPIKeysPerUser    = 10
+	apiKeyIdNumBytes     = 8
+	apiKeySecretNumBytes = 32
+)
+
+// metadata of an API key, the key itself is never stored
+type APIKeyRecord struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Hash      string    `json:"-"`
+}
+
+// API keys are stored with the hashes of the keys, which is sufficient for random keys with high entropy
+type apiKeyData struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Hash      string    `json:"hash"`
+}
+
+// API keys of an user are stored in a hash with key api_keys:user:username, fields are key IDs
+// the username is the last part of the key, so that keys of different users never collide
+func userAPIKeysRedisKey(username string) string {
+	return strings.Join([]string{UserAPIKeysKeyPrefix, UserKeyPrefix, username}, ":")
+}
+
+// reverse lookup from the hash of an API key to its owner
+func apiKeyRedisKey(hash string) string {
+	return strings.Join([]string{APIKeyKeyPrefix, hash}, ":")
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// IsAPIKey tells API keys from other credentials such as JWT
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix+"_")
+}
+
+// create a named API key for an user
+// the key is returned only once and cannot be recovered later
+func (redisClient *RedisClient) CreateAPIKey(username string, name string) (key string, record APIKeyRecord, err error) {
+	numKeys, err := redisClient.client.HLen(userAPIKeysRedisKey(username)).Result()
+	if err != nil {
+		return
+	}
+	if numKeys >= MaxAPIKeysPerUser {
+		err = errors.New("maximum number of API keys reached")
+		return
+	}
+
+	keyId, err := utils.GenerateRandomToken(apiKeyIdNumBytes)
+	if err != nil {
+		return
+	}
+	secret, err := utils.GenerateRandomToken(apiKeySecretNumBytes)
+	if err != nil {
+		return
+	}
+	key = strings.Join([]string{APIKeyPrefix, keyId, secret}, "_")
+
+	record = APIKeyRecord{ID: keyId, Name: name, CreatedAt: time.Now(), Hash: hashSecret(key)}
+	json_, err := json.Marshal(apiKeyData(record))
+	if err != nil {
+		return
+	}
+
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.HSet(userAPIKeysRedisKey(username), keyId, string(json_))
+	pipeline.HMSet(apiKeyRedisKey(record.Hash), map[string]interface{}{"username": username, "key_id": keyId})
+	_, err = pipeline.Exec()
+	return
+}
+
+// list API keys of an user from the oldest to the newest
+func (redisClient *RedisClient) ListAPIKeys(username string) (records []APIKeyRecord, err error) {
+	keysData, err := redisClient.client.HGetAll(userAPIKeysRedisKey(username)).Result()
+	if err != nil {
+		return
+	}
+
+	records = make([]APIKeyRecord, 0, len(keysData))
+	for _, json_ := range keysData {
+		data := apiKeyData{}
+		if utils.CheckErrImmediate(json.Unmarshal([]byte(json_), &data), utils.LogError) {
+			continue
+		}
+		records = append(records, APIKeyRecord(data))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return
+}
+
+// revoke an API key of an user, the key cannot be used from now on
+func (redisClient *RedisClient) RevokeAPIKey(username string, keyId string) (err error) {
+	json_, err := redisClient.client.HGet(userAPIKeysRedisKey(username), keyId).Result()
+	if err == redis.Nil {
+		return errors.New("API key does not exist")
+	}
+	if err != nil {
+		return
+	}
+
+	data := apiKeyData{}
+	if err = json.Unmarshal([]byte(json_), &data); err != nil {
+		return
+	}
+
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.HDel(userAPIKeysRedisKey(username), keyId)
+	pipeline.Del(apiKeyRedisKey(data.Hash))
+	_, err = pipeline.Exec()
+	return
+}
+
+// revoke all API keys of an user
+func (redisClient *RedisClient) RevokeAPIKeys(username string) error {
+	apiKeys, err := redisClient.client.HGetAll(userAPIKeysRedisKey(username)).Result()
+	if err != nil {
+		return err
+	}
+	for keyId := range apiKeys {
+		if err = redisClient.RevokeAPIKey(username, keyId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// find the owner and the ID of an API key
+func (redisClient *RedisClient) AuthenticateAPIKey(key string) (username string, keyId string, err error) {
+	if !IsAPIKey(key) {
+		err = errors.New("invalid API key")
+		return
+	}
+
+	keyData, err := redisClient.client.HGetAll(apiKeyRedisKey(hashSecret(key))).Result()
+	if err != nil {
+		return
+	}
+	if len(keyData) == 0 {
+		err = errors.New("invalid API key")
+		return
+	}
+	return keyData["username"], keyData["key_id"], nil
+}
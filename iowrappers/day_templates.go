@@ -0,0 +1,87 @@
+package iowrappers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v7"
+	"sort"
+	"strings"
+)
+
+const (
+	DayTemplatesKeyPrefix  = "day_templates"
+	MaxDayTemplatesPerUser = 20
+)
+
+// templates of an user are stored in a hash with key day_templates:username, fields are template names
+func dayTemplatesRedisKey(username string) string {
+	return strings.Join([]string{DayTemplatesKeyPrefix, username}, ":")
+}
+
+// serialize a day template using JSON and store it under its name
+// an existing template with the same name is replaced
+func (redisClient *RedisClient) SaveDayTemplate(username string, name string, template interface{}) (err error) {
+	json_, err := json.Marshal(template)
+	if err != nil {
+		return
+	}
+
+	redisKey := dayTemplatesRedisKey(username)
+	exists, err := redisClient.client.HExists(redisKey, name).Result()
+	if err != nil {
+		return
+	}
+	if !exists {
+		numTemplates, lenErr := redisClient.client.HLen(redisKey).Result()
+		if lenErr != nil {
+			return lenErr
+		}
+		if numTemplates >= MaxDayTemplatesPerUser {
+			return errors.New("maximum number of templates reached")
+		}
+	}
+	return redisClient.client.HSet(redisKey, name, string(json_)).Err()
+}
+
+// retrieve a day template and de-serialize it into the template parameter
+func (redisClient *RedisClient) GetDayTemplate(username string, name string, template interface{}) (err error) {
+	json_, err := redisClient.client.HGet(dayTemplatesRedisKey(username), name).Result()
+	if err == redis.Nil {
+		return errors.New("template does not exist")
+	}
+	if err != nil {
+		return
+	}
+	return json.Unmarshal([]byte(json_), template)
+}
+
+// list serialized day templates of an user ordered by name
+func (redisClient *RedisClient) ListDayTemplates(username string) (templates []json.RawMessage, err error) {
+	templateData, err := redisClient.client.HGetAll(dayTemplatesRedisKey(username)).Result()
+	if err != nil {
+		return
+	}
+
+	names := make([]string, 0, len(templateData))
+	for name := range templateData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	templates = make([]json.RawMessage, len(names))
+	for idx, name := range names {
+		templates[idx] = json.RawMessage(templateData[name])
+	}
+	return
+}
+
+func (redisClient *RedisClient) DeleteDayTemplate(username string, name string) (err error) {
+	numDeleted, err := redisClient.client.HDel(dayTemplatesRedisKey(username), name).Result()
+	if err != nil {
+		return
+	}
+	if numDeleted == 0 {
+		return errors.New("template does not exist")
+	}
+	return
+}
@@ -3,7 +3,6 @@ package iowrappers
 import (
 	"errors"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	"github.com/weihesdlegend/Vacation-planner/POI"
@@ -48,6 +47,7 @@ type CollHandler struct {
 
 type PlanningEvent struct {
 	User      string `json:"user"`
+	APIKeyID  string `json:"api_key_id,omitempty"` // ID of the API key used to make the request
 	City      string `json:"city"`
 	Country   string `json:"country"`
 	Timestamp string `json:"timestamp"`
@@ -66,12 +66,37 @@ func (dbHandler DbHandler) CreatePlanningEvent(event PlanningEvent) {
 	_ = dbHandler.handlers[PlanningEventsCollection].GetCollection().Insert(event)
 }
 
+// archive planning events from the Redis stream
+// stream entry IDs are document IDs, so archiving the same events again does not create duplicates
+func (dbHandler *DbHandler) ArchivePlanningEvents(entries []PlanningStreamEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	bulk := dbHandler.handlers[PlanningEventsCollection].GetCollection().Bulk()
+	bulk.Unordered()
+	for _, entry := range entries {
+		bulk.Upsert(bson.M{"_id": entry.ID}, entry)
+	}
+	_, err := bulk.Run()
+	return err
+}
+
 func (dbHandler *DbHandler) CreateSession(uri string) {
 	session, err := mgo.Dial(uri)
 	utils.CheckErrImmediate(err, utils.LogError)
 	dbHandler.Session = session
 }
 
+// check the connection to MongoDB with a copy of the session, so that a broken socket is not reused
+func (dbHandler *DbHandler) Ping() error {
+	if dbHandler.Session == nil {
+		return errors.New("MongoDB session does not exist")
+	}
+	session := dbHandler.Session.Copy()
+	defer session.Close()
+	return session.Ping()
+}
+
 func (dbHandler *DbHandler) SetCollHandler(collectionName string) {
 	if _, exist := dbHandler.handlers[collectionName]; !exist {
 		collHandler := &CollHandler{}
@@ -151,16 +176,7 @@ func (dbHandler *DbHandler) UserLogin(credential user.Credential, issueJWT bool)
 
 	// issue JWT
 	if issueJWT {
-		tokenExpirationTime = lastLoginTime.Add(user.JWTExpirationTime)
-		expiresAt := tokenExpirationTime.Unix() // expires after 10 days
-		jwtSigningSecret := os.Getenv("JWT_SIGNING_SECRET")
-
-		jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"username":       u.Username,
-			"StandardClaims": jwt.StandardClaims{ExpiresAt: expiresAt},
-		})
-
-		token, err = jwtToken.SignedString([]byte(jwtSigningSecret))
+		token, tokenExpirationTime, err = IssueToken(u.Username, user.AccessToken, 0, lastLoginTime)
 	}
 	return
 }
@@ -16,6 +16,7 @@ func (mapsClient MapsClient) GetGeocode(query *GeocodeQuery) (lat float64, lng f
 		}}
 
 	resp, err := mapsClient.client.Geocode(context.Background(), req)
+	countMapsAPICall(MapsCallGeocode, err)
 	if err != nil {
 		utils.CheckErrImmediate(err, utils.LogError)
 		return
@@ -0,0 +1,92 @@
+package iowrappers
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MailerSMTP = "smtp"
+	MailerFile = "file"
+	MailerLog  = "log"
+)
+
+// an email message in plain text
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+// send emails through a SMTP server with PLAIN authentication
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (mailer *SMTPMailer) Send(mail Mail) error {
+	var auth smtp.Auth
+	if mailer.Username != "" {
+		auth = smtp.PlainAuth("", mailer.Username, mailer.Password, mailer.Host)
+	}
+	addr := net.JoinHostPort(mailer.Host, strconv.Itoa(mailer.Port))
+	return smtp.SendMail(addr, auth, mailer.From, []string{mail.To}, formatMail(mailer.From, mail))
+}
+
+// write emails to files in a directory, e.g. for local development
+type FileMailer struct {
+	Dir string
+}
+
+func (mailer *FileMailer) Send(mail Mail) error {
+	if err := os.MkdirAll(mailer.Dir, 0755); err != nil {
+		return err
+	}
+	filename := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, mail.To))
+	return ioutil.WriteFile(filepath.Join(mailer.Dir, filename), formatMail("", mail), 0600)
+}
+
+// log the recipients and subjects of emails instead of sending them, e.g. for local development
+// bodies are never logged because they contain tokens for email verification and password reset
+type LogMailer struct{}
+
+func (mailer *LogMailer) Send(mail Mail) error {
+	log.Infof("email to %s is not sent, subject: %s", mail.To, mail.Subject)
+	return nil
+}
+
+// RFC 5322 message with headers, header values cannot contain line breaks
+func formatMail(from string, mail Mail) []byte {
+	removeLineBreaks := strings.NewReplacer("\r", "", "\n", "")
+	var sb strings.Builder
+	if from != "" {
+		sb.WriteString("From: " + removeLineBreaks.Replace(from) + "\r\n")
+	}
+	sb.WriteString("To: " + removeLineBreaks.Replace(mail.To) + "\r\n")
+	sb.WriteString("Subject: " + removeLineBreaks.Replace(mail.Subject) + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	return []byte(sb.String())
+}
@@ -0,0 +1,77 @@
+package iowrappers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"time"
+)
+
+const (
+	MetricsNamespace = "vacation_planner"
+
+	SolverStageGeocode      = "geocode"
+	SolverStageNearbySearch = "nearby_search"
+	SolverStageMatching     = "matching"
+	SolverStageDFSRanking   = "dfs_ranking"
+
+	FulfilledByRedis = "redis"
+	FulfilledByMaps  = "maps"
+
+	MapsCallGeocode      = "geocode"
+	MapsCallNearbySearch = "nearby_search"
+	MapsCallPlaceDetails = "place_details"
+	MapsCallTimezone     = "timezone"
+)
+
+// metrics are registered in the default Prometheus registry and exposed by the /metrics endpoint
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by endpoint.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "endpoint", "status"})
+
+	SolverStageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "solver_stage_duration_seconds",
+		Help:      "Duration of the stages of solving planning requests.",
+		Buckets:   []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"stage"})
+
+	SlotSolutionCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "slot_solution_cache_lookups_total",
+		Help:      "Slot solution cache lookups by result, hit or miss.",
+	}, []string{"result"})
+
+	NearbySearchFulfilments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "nearby_search_fulfilments_total",
+		Help:      "Nearby searches by the source fulfilling them, redis or maps.",
+	}, []string{"source", "category"})
+
+	MapsAPICalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "maps_api_calls_total",
+		Help:      "Calls to the Google Maps APIs by type and result.",
+	}, []string{"type", "result"})
+
+	PlanningEventsQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "planning_events_queue_depth",
+		Help:      "Number of planning events waiting to be processed.",
+	})
+)
+
+func ObserveSolverStage(stage string, duration time.Duration) {
+	SolverStageDuration.WithLabelValues(stage).Observe(duration.Seconds())
+}
+
+func countMapsAPICall(callType string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	MapsAPICalls.WithLabelValues(callType, result).Inc()
+}
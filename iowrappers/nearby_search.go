@@ -53,6 +53,7 @@ func GoogleMapsNearbySearchWrapper(c MapsClient, location string, placeType stri
 		RankBy:    maps.RankBy(rankBy),
 	}
 	resp, err = c.client.NearbySearch(context.Background(), &mapsReq)
+	countMapsAPICall(MapsCallNearbySearch, err)
 	logErr(err, utils.LogError)
 	return
 }
@@ -187,6 +188,7 @@ func PlaceDetailedSearch(mapsClient *MapsClient, placeId string) (maps.PlaceDeta
 	startSearchTime := time.Now()
 
 	resp, err := mapsClient.client.PlaceDetails(context.Background(), req)
+	countMapsAPICall(MapsCallPlaceDetails, err)
 	utils.CheckErrImmediate(err, utils.LogError)
 
 	searchDuration := time.Since(startSearchTime)
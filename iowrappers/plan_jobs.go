@@ -0,0 +1,118 @@
+package iowrappers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"strings"
+	"time"
+)
+
+const (
+	PlanJobKeyPrefix  = "plan_job"
+	PlanJobExpiration = 24 * time.Hour
+	// queued or running jobs without updates are lost, e.g. when the server instance of the job crashed
+	PlanJobTimeout    = 30 * time.Minute
+	PlanJobTimeoutErr = "planning job timed out"
+	planJobIdNumBytes = 16
+)
+
+// life cycle of an asynchronous planning job
+const (
+	PlanJobQueued  = "queued"
+	PlanJobRunning = "running"
+	PlanJobDone    = "done"
+	PlanJobFailed  = "failed"
+)
+
+// state of an asynchronous planning job
+// result is the serialized planning response once the job is done or failed
+type PlanJob struct {
+	ID        string          `json:"id"`
+	Owner     string          `json:"owner"`
+	Status    string          `json:"status"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Err       string          `json:"error,omitempty"`
+}
+
+func planJobRedisKey(jobId string) string {
+	return strings.Join([]string{PlanJobKeyPrefix, jobId}, ":")
+}
+
+// create a queued job with key plan_job:jobID
+// job state expires after PlanJobExpiration so that abandoned jobs do not accumulate
+func (redisClient *RedisClient) CreatePlanJob(username string) (jobId string, err error) {
+	jobId, err = utils.GenerateRandomToken(planJobIdNumBytes)
+	if err != nil {
+		return
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	jobData := map[string]interface{}{
+		"owner":      username,
+		"status":     PlanJobQueued,
+		"created_at": now,
+		"updated_at": now,
+	}
+
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.HMSet(planJobRedisKey(jobId), jobData)
+	pipeline.Expire(planJobRedisKey(jobId), PlanJobExpiration)
+	_, err = pipeline.Exec()
+	return
+}
+
+// update status of a job, result is serialized using JSON if it is not nil
+func (redisClient *RedisClient) UpdatePlanJob(jobId string, status string, result interface{}, errMsg string) (err error) {
+	jobData := map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now().Format(time.RFC3339),
+		"error":      errMsg,
+	}
+	if result != nil {
+		json_, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		jobData["result"] = string(json_)
+	}
+
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.HMSet(planJobRedisKey(jobId), jobData)
+	pipeline.Expire(planJobRedisKey(jobId), PlanJobExpiration)
+	_, err = pipeline.Exec()
+	return
+}
+
+func (redisClient *RedisClient) GetPlanJob(jobId string) (job PlanJob, err error) {
+	jobData, err := redisClient.client.HGetAll(planJobRedisKey(jobId)).Result()
+	if err != nil {
+		return
+	}
+	if len(jobData) == 0 {
+		err = errors.New("planning job does not exist")
+		return
+	}
+
+	createdAt, _ := time.Parse(time.RFC3339, jobData["created_at"])
+	updatedAt, _ := time.Parse(time.RFC3339, jobData["updated_at"])
+	job = PlanJob{
+		ID:        jobId,
+		Owner:     jobData["owner"],
+		Status:    jobData["status"],
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		Err:       jobData["error"],
+	}
+	if result := jobData["result"]; result != "" {
+		job.Result = json.RawMessage(result)
+	}
+
+	if (job.Status == PlanJobQueued || job.Status == PlanJobRunning) && time.Since(job.UpdatedAt) > PlanJobTimeout {
+		job.Status, job.Err = PlanJobFailed, PlanJobTimeoutErr
+		err = redisClient.UpdatePlanJob(jobId, job.Status, nil, job.Err)
+	}
+	return
+}
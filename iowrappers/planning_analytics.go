@@ -0,0 +1,111 @@
+package iowrappers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HourlyVisitorsKeyPrefix = "hourly"
+	HourlyVisitorsLayout    = "2006010215"
+	// hourly unique visitor counts are kept for 31 days
+	HourlyVisitorsExpirationTime = 31 * 24 * time.Hour
+	planningEventsBatchSize      = 1000
+)
+
+// a planning event read from the planning API usage stream
+// stream values are in lowercase, times are from the stream entry IDs
+type PlanningStreamEntry struct {
+	ID       string    `json:"id" bson:"_id"`
+	Time     time.Time `json:"time" bson:"time"`
+	User     string    `json:"user" bson:"user"`
+	City     string    `json:"city" bson:"city"`
+	Country  string    `json:"country" bson:"country"`
+	APIKeyID string    `json:"api_key_id,omitempty" bson:"api_key_id,omitempty"`
+}
+
+// unique visitors of each hour are counted in a HyperLogLog with key visitor_count:hourly:YYYYMMDDHH in UTC
+func hourlyVisitorsRedisKey(hour time.Time) string {
+	return strings.Join([]string{NumVisitorsPrefix, HourlyVisitorsKeyPrefix, hour.UTC().Format(HourlyVisitorsLayout)}, ":")
+}
+
+func (redisClient *RedisClient) collectHourlyVisitors(event PlanningEvent, eventTime time.Time) error {
+	redisKey := hourlyVisitorsRedisKey(eventTime)
+	pipeline := redisClient.client.Pipeline()
+	pipeline.PFAdd(redisKey, event.User)
+	pipeline.Expire(redisKey, HourlyVisitorsExpirationTime)
+	_, err := pipeline.Exec()
+	return err
+}
+
+// count unique visitors of the planning APIs in the hours overlapping with [start, end)
+// PFCOUNT of multiple keys counts the union of the HyperLogLogs
+func (redisClient *RedisClient) CountUniqueVisitors(start time.Time, end time.Time) (int64, error) {
+	keys := make([]string, 0)
+	for hour := start.UTC().Truncate(time.Hour); hour.Before(end); hour = hour.Add(time.Hour) {
+		keys = append(keys, hourlyVisitorsRedisKey(hour))
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return redisClient.client.PFCount(keys...).Result()
+}
+
+// count unique visitors of the planning APIs in the global HyperLogLog
+func (redisClient *RedisClient) CountAllUniqueVisitors() (int64, error) {
+	return redisClient.client.PFCount(NumVisitorsPlanningAPI).Result()
+}
+
+// read planning events in [start, end) from the stream in batches
+// at most maxEvents events are returned, truncated is true if there are more events in the time range
+func (redisClient *RedisClient) ReadPlanningEvents(streamName string, start time.Time, end time.Time, maxEvents int) (entries []PlanningStreamEntry, truncated bool, err error) {
+	entries = make([]PlanningStreamEntry, 0)
+	startId := strconv.FormatInt(toMilliseconds(start), 10)
+	endId := strconv.FormatInt(toMilliseconds(end)-1, 10)
+	for {
+		messages, rangeErr := redisClient.client.XRangeN(streamName, startId, endId, planningEventsBatchSize).Result()
+		if rangeErr != nil {
+			return entries, false, rangeErr
+		}
+		for _, message := range messages {
+			if len(entries) == maxEvents {
+				return entries, true, nil
+			}
+			entries = append(entries, toPlanningStreamEntry(message.ID, message.Values))
+		}
+		if len(messages) < planningEventsBatchSize {
+			return
+		}
+		startId = nextStreamId(messages[len(messages)-1].ID)
+	}
+}
+
+func toMilliseconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// the smallest stream ID after an ID in the format of milliseconds-sequence
+func nextStreamId(id string) string {
+	fields := strings.SplitN(id, "-", 2)
+	if len(fields) != 2 {
+		return id
+	}
+	sequence, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return id
+	}
+	return fields[0] + "-" + strconv.FormatUint(sequence+1, 10)
+}
+
+func toPlanningStreamEntry(id string, values map[string]interface{}) PlanningStreamEntry {
+	entry := PlanningStreamEntry{ID: id}
+	if milliseconds, err := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64); err == nil {
+		entry.Time = time.Unix(0, milliseconds*int64(time.Millisecond)).UTC()
+	}
+	entry.User, _ = values["user"].(string)
+	entry.City, _ = values["city"].(string)
+	entry.Country, _ = values["country"].(string)
+	entry.APIKeyID, _ = values["api_key_id"].(string)
+	return entry
+}
@@ -19,6 +19,19 @@ const (
 type PoiSearcher struct {
 	mapsClient  MapsClient
 	redisClient RedisClient
+	config      PoiSearcherConfig
+}
+
+type PoiSearcherConfig struct {
+	MaxSearchRadius              uint          // meters, the radius of searches in Redis and in Google Maps
+	MinMapsResultRefreshDuration time.Duration // Google Maps is not searched again for a location within the duration
+}
+
+func DefaultPoiSearcherConfig() PoiSearcherConfig {
+	return PoiSearcherConfig{
+		MaxSearchRadius:              MaxSearchRadius,
+		MinMapsResultRefreshDuration: MinMapsResultRefreshDuration,
+	}
 }
 
 type GeocodeQuery struct {
@@ -28,9 +41,10 @@ type GeocodeQuery struct {
 
 var Logger *zap.SugaredLogger
 
-func (poiSearcher *PoiSearcher) Init(mapsApiKey string, redisUrl *url.URL) {
+func (poiSearcher *PoiSearcher) Init(mapsApiKey string, redisUrl *url.URL, config PoiSearcherConfig) {
 	poiSearcher.mapsClient = CreateMapsClient(mapsApiKey)
 	poiSearcher.redisClient = CreateRedisClient(redisUrl)
+	poiSearcher.config = config
 }
 
 func (poiSearcher *PoiSearcher) GetMapsClient() *MapsClient {
@@ -76,7 +90,7 @@ func (poiSearcher *PoiSearcher) NearbySearch(request *PlaceSearchRequest) (place
 	request.Location = fmt.Sprint(lat) + "," + fmt.Sprint(lng)
 
 	var cachedPlaces []POI.Place
-	cachedPlaces, err = poiSearcher.redisClient.NearbySearch(request)
+	cachedPlaces, err = poiSearcher.redisClient.NearbySearch(request, poiSearcher.config.MaxSearchRadius)
 	if err != nil {
 		Logger.Error(err)
 	}
@@ -86,8 +100,9 @@ func (poiSearcher *PoiSearcher) NearbySearch(request *PlaceSearchRequest) (place
 	lastSearchTime, cacheErr := poiSearcher.redisClient.GetMapsLastSearchTime(location, request.PlaceCat)
 
 	currentTime := time.Now()
-	if uint(len(cachedPlaces)) >= request.MinNumResults || currentTime.Sub(lastSearchTime) <= MinMapsResultRefreshDuration {
+	if uint(len(cachedPlaces)) >= request.MinNumResults || currentTime.Sub(lastSearchTime) <= poiSearcher.config.MinMapsResultRefreshDuration {
 		Logger.Infof("Using Redis to fulfill request. Place Type: %s", request.PlaceCat)
+		NearbySearchFulfilments.WithLabelValues(FulfilledByRedis, string(request.PlaceCat)).Inc()
 		maxResultNum := utils.MinInt(len(cachedPlaces), int(request.MaxNumResults))
 		places = append(places, cachedPlaces[:maxResultNum]...)
 		return
@@ -100,9 +115,10 @@ func (poiSearcher *PoiSearcher) NearbySearch(request *PlaceSearchRequest) (place
 
 	originalSearchRadius := request.Radius
 
-	request.Radius = MaxSearchRadius // use a large search radius whenever we call external maps services
+	request.Radius = poiSearcher.config.MaxSearchRadius // use a large search radius whenever we call external maps services
 
 	// initiate a new external search
+	NearbySearchFulfilments.WithLabelValues(FulfilledByMaps, string(request.PlaceCat)).Inc()
 	newPlaces, mapsNearbySearchErr := poiSearcher.mapsClient.NearbySearch(request)
 	utils.CheckErrImmediate(mapsNearbySearchErr, utils.LogError)
 
@@ -0,0 +1,49 @@
+package iowrappers
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	RateLimitKeyPrefix           = "rate_limit"
+	RateLimitExemptUsersRedisKey = "rate_limit_exempt_users"
+)
+
+func rateLimitRedisKey(scope string, identity string) string {
+	return strings.Join([]string{RateLimitKeyPrefix, scope, identity}, ":")
+}
+
+// count a request in the current fixed window of a rate limit
+// the counter expires at the end of the window, which is also the time to wait for when the limit is exceeded
+func (redisClient *RedisClient) IncrementRateLimitCounter(scope string, identity string, window time.Duration) (count int64, ttl time.Duration, err error) {
+	redisKey := rateLimitRedisKey(scope, identity)
+
+	// the counter is created with the expiration of the window in the same transaction as the increment
+	// so that a counter never lives without an expiration
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.SetNX(redisKey, 0, window)
+	incrCmd := pipeline.Incr(redisKey)
+	ttlCmd := pipeline.PTTL(redisKey)
+	if _, err = pipeline.Exec(); err != nil {
+		return
+	}
+	count, ttl = incrCmd.Val(), ttlCmd.Val()
+	return
+}
+
+// exempt an user from rate limits or revoke the exemption
+func (redisClient *RedisClient) SetRateLimitExemption(username string, exempt bool) error {
+	if exempt {
+		return redisClient.client.SAdd(RateLimitExemptUsersRedisKey, username).Err()
+	}
+	return redisClient.client.SRem(RateLimitExemptUsersRedisKey, username).Err()
+}
+
+func (redisClient *RedisClient) IsRateLimitExempt(username string) (bool, error) {
+	return redisClient.client.SIsMember(RateLimitExemptUsersRedisKey, username).Result()
+}
+
+func (redisClient *RedisClient) ListRateLimitExemptUsers() ([]string, error) {
+	return redisClient.client.SMembers(RateLimitExemptUsersRedisKey).Result()
+}
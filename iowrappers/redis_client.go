@@ -39,6 +39,7 @@ func (redisClient *RedisClient) Destroy() {
 
 // analytics of total number of unique visitors to the planning APIs in the last 24 hours
 // analytics of number of unique users planning for each city
+// analytics of unique visitors in each hour
 func (redisClient *RedisClient) CollectPlanningAPIStats(event PlanningEvent) {
 	c := redisClient.client
 
@@ -58,9 +59,22 @@ func (redisClient *RedisClient) CollectPlanningAPIStats(event PlanningEvent) {
 	if _, err := pipeline.Exec(); err != nil {
 		log.Error(err)
 	}
+
+	eventTime, err := time.Parse(time.RFC3339, event.Timestamp)
+	if err != nil {
+		eventTime = time.Now()
+	}
+	if err = redisClient.collectHourlyVisitors(event, eventTime); err != nil {
+		log.Error(err)
+	}
 }
 
 // factory method for RedisClient
+// check the connection to Redis
+func (redisClient *RedisClient) Ping() error {
+	return redisClient.client.Ping().Err()
+}
+
 func CreateRedisClient(url *url.URL) RedisClient {
 	password, _ := url.User.Password()
 	return RedisClient{client: *redis.NewClient(&redis.Options{
@@ -191,7 +205,8 @@ func (redisClient *RedisClient) NearbySearchNotUsed(request *PlaceSearchRequest)
 	return res, nil
 }
 
-func (redisClient *RedisClient) NearbySearch(request *PlaceSearchRequest) (places []POI.Place, err error) {
+// the search radius is limited to the max search radius, the request is not modified
+func (redisClient *RedisClient) NearbySearch(request *PlaceSearchRequest, maxSearchRadius uint) (places []POI.Place, err error) {
 	requestCategory := strings.ToLower(string(request.PlaceCat))
 	redisKey := "placeIDs:" + requestCategory
 
@@ -199,9 +214,8 @@ func (redisClient *RedisClient) NearbySearch(request *PlaceSearchRequest) (place
 	requestLat, requestLng := latLng[0], latLng[1]
 
 	searchRadius := request.Radius
-
-	if searchRadius > MaxSearchRadius {
-		searchRadius = MaxSearchRadius
+	if searchRadius > maxSearchRadius {
+		searchRadius = maxSearchRadius
 	}
 
 	Logger.Debugf("Redis geo radius is using search radius of %d meters", searchRadius)
@@ -217,8 +231,6 @@ func (redisClient *RedisClient) NearbySearch(request *PlaceSearchRequest) (place
 		return
 	}
 
-	request.Radius = searchRadius
-
 	places = make([]POI.Place, 0)
 	for _, placeInfo := range cachedQualifiedPlaces {
 		place, err := redisClient.getPlace(placeInfo.Name)
@@ -328,42 +340,44 @@ type SlotSolutionCacheRequest struct {
 	EVTags    []string
 	Intervals []POI.TimeInterval
 	Weekday   POI.Weekday
+	// slot solutions depend on the solver config, solutions of other configs are not used
+	ConfigFingerprint string
 }
 
-// convert time intervals and an EV tag to an integer
-// each time interval and E/V pair has 23 * 24 * 2 = 1104 possibilities
-// treat each pair as one digit in 1104-ary number and we have maximum 4 digits
-func encodeTimeCatIdx(eVTag []string, intervals []POI.TimeInterval) (res int64, err error) {
+// convert time intervals and an EV tag to a string
+// each E/V and time interval pair is encoded as the tag followed by start and end minutes of the day, e.g. "e540-600"
+func encodeTimeCategories(eVTag []string, intervals []POI.TimeInterval) (res string, err error) {
 	if len(eVTag) != len(intervals) {
 		err = errors.New("wrong inputs")
-		res = -1
 		return
 	}
+	timeCategories := make([]string, len(eVTag))
 	for idx, tagVal := range eVTag {
-		res *= 1104
-		interval := intervals[idx]
-		if strings.ToLower(tagVal) == "e" {
-			res += int64(interval.Start) * int64(interval.End)
-		} else if strings.ToLower(tagVal) == "v" {
-			res += int64(interval.Start) * int64(interval.End) * 2
-		} else {
+		tag := strings.ToLower(tagVal)
+		if tag != "e" && tag != "v" {
 			err = errors.New("wrong input EV tag")
-			res = -1
 			return
 		}
+		interval := intervals[idx]
+		timeCategories[idx] = tag + strconv.Itoa(int(interval.Start)) + "-" + strconv.Itoa(int(interval.End))
 	}
+	res = strings.Join(timeCategories, "_")
 	return
 }
 
 func genSlotSolutionCacheKey(req SlotSolutionCacheRequest) string {
 	country, city := req.Country, req.City
-	timeCatIdx, err := encodeTimeCatIdx(req.EVTags, req.Intervals)
+	timeCategories, err := encodeTimeCategories(req.EVTags, req.Intervals)
 	utils.CheckErrImmediate(err, utils.LogError)
 
 	radius := strconv.FormatUint(req.Radius, 10)
-	timeCatIdxStr := strconv.FormatInt(timeCatIdx, 10)
+	weekday := strconv.FormatUint(uint64(req.Weekday), 10)
 
-	redisFieldKey := strings.ToLower(strings.Join([]string{"slot_solution", country, city, radius, string(req.Weekday), timeCatIdxStr}, ":"))
+	keyFields := []string{"slot_solution", country, city, radius, weekday, timeCategories}
+	if req.ConfigFingerprint != "" {
+		keyFields = append(keyFields, req.ConfigFingerprint)
+	}
+	redisFieldKey := strings.ToLower(strings.Join(keyFields, ":"))
 	return redisFieldKey
 }
 
@@ -409,5 +423,13 @@ func (redisClient *RedisClient) GetMultiSlotSolutions(requests []SlotSolutionCac
 		go redisClient.GetSlotSolution(redisKey, responses, &wg, idx)
 	}
 	wg.Wait()
+
+	for _, response := range responses {
+		if response.Err == nil {
+			SlotSolutionCacheLookups.WithLabelValues("hit").Inc()
+		} else {
+			SlotSolutionCacheLookups.WithLabelValues("miss").Inc()
+		}
+	}
 	return
 }
@@ -0,0 +1,244 @@
+package iowrappers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v7"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	PlanKeyPrefix            = "plan"
+	UserPlansKeyPrefix       = "user_plans"
+	PlanShareTokenKeyPrefix  = "plan_share_token"
+	PlanShareTokensKeyPrefix = "plan_share_tokens"
+	planIdNumBytes           = 16
+	planShareTokenNumBytes   = 32
+	// the oldest plans of an user are deleted once the plan history exceeds the limit
+	MaxPlansPerUser = 100
+)
+
+// metadata of a persisted plan
+type PlanRecord struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"owner"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func planRedisKey(planId string) string {
+	return strings.Join([]string{PlanKeyPrefix, planId}, ":")
+}
+
+func userPlansRedisKey(username string) string {
+	return strings.Join([]string{UserPlansKeyPrefix, username}, ":")
+}
+
+func planShareTokenRedisKey(token string) string {
+	return strings.Join([]string{PlanShareTokenKeyPrefix, token}, ":")
+}
+
+func planShareTokensRedisKey(planId string) string {
+	return strings.Join([]string{PlanShareTokensKeyPrefix, planId}, ":")
+}
+
+// serialize plan using JSON and store it in Redis with key plan:planID
+// plan IDs of an user are stored in a sorted set with key user_plans:username ordered by creation time
+// plans expire after expiration unless it is zero, and at most MaxPlansPerUser plans are kept for each user
+func (redisClient *RedisClient) SavePlan(username string, title string, plan interface{}, expiration time.Duration) (planId string, err error) {
+	json_, err := json.Marshal(plan)
+	if err != nil {
+		return
+	}
+
+	planId, err = utils.GenerateRandomToken(planIdNumBytes)
+	if err != nil {
+		return
+	}
+
+	createdAt := time.Now()
+	planData := map[string]interface{}{
+		"owner":      username,
+		"title":      title,
+		"created_at": createdAt.Format(time.RFC3339),
+		"plan":       string(json_),
+	}
+
+	userPlansKey := userPlansRedisKey(username)
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.HMSet(planRedisKey(planId), planData)
+	// scores are in milliseconds to order plans saved in the same second
+	pipeline.ZAdd(userPlansKey, &redis.Z{Score: float64(unixMilli(createdAt)), Member: planId})
+	if expiration > 0 {
+		pipeline.Expire(planRedisKey(planId), expiration)
+		// plans saved before the expiration window are already gone
+		pipeline.ZRemRangeByScore(userPlansKey, "-inf", "("+strconv.FormatInt(unixMilli(createdAt.Add(-expiration)), 10))
+		pipeline.Expire(userPlansKey, expiration)
+	}
+	if _, err = pipeline.Exec(); err != nil {
+		return
+	}
+
+	err = redisClient.trimUserPlans(username)
+	return
+}
+
+// delete the oldest plans of an user beyond MaxPlansPerUser
+func (redisClient *RedisClient) trimUserPlans(username string) error {
+	oldPlanIds, err := redisClient.client.ZRange(userPlansRedisKey(username), 0, -MaxPlansPerUser-1).Result()
+	if err != nil || len(oldPlanIds) == 0 {
+		return err
+	}
+	return redisClient.deletePlans(username, oldPlanIds)
+}
+
+// retrieve a plan and de-serialize it into the plan parameter
+func (redisClient *RedisClient) GetPlan(planId string, plan interface{}) (record PlanRecord, err error) {
+	planData, err := redisClient.client.HGetAll(planRedisKey(planId)).Result()
+	if err != nil {
+		return
+	}
+	if len(planData) == 0 {
+		err = errors.New("plan does not exist")
+		return
+	}
+
+	record = toPlanRecord(planId, planData)
+	err = json.Unmarshal([]byte(planData["plan"]), plan)
+	return
+}
+
+// list plans of an user from the newest to the oldest
+// returns the total number of plans of the user for pagination
+func (redisClient *RedisClient) ListUserPlans(username string, offset int64, count int64) (records []PlanRecord, total int64, err error) {
+	redisKey := userPlansRedisKey(username)
+	total, err = redisClient.client.ZCard(redisKey).Result()
+	if err != nil {
+		return
+	}
+
+	planIds, err := redisClient.client.ZRevRange(redisKey, offset, offset+count-1).Result()
+	if err != nil {
+		return
+	}
+
+	pipeline := redisClient.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(planIds))
+	for idx, planId := range planIds {
+		cmds[idx] = pipeline.HMGet(planRedisKey(planId), "owner", "title", "created_at")
+	}
+	if len(planIds) > 0 {
+		if _, err = pipeline.Exec(); err != nil {
+			return
+		}
+	}
+
+	records = make([]PlanRecord, 0)
+	for idx, cmd := range cmds {
+		values := cmd.Val()
+		planData := make(map[string]string)
+		for fieldIdx, field := range []string{"owner", "title", "created_at"} {
+			if value, ok := values[fieldIdx].(string); ok {
+				planData[field] = value
+			}
+		}
+		records = append(records, toPlanRecord(planIds[idx], planData))
+	}
+	return
+}
+
+// remove a plan and its reference in the plan history of its owner
+func (redisClient *RedisClient) DeletePlan(planId string) (err error) {
+	owner, err := redisClient.client.HGet(planRedisKey(planId), "owner").Result()
+	if err == redis.Nil {
+		return errors.New("plan does not exist")
+	}
+	if err != nil {
+		return
+	}
+
+	return redisClient.deletePlans(owner, []string{planId})
+}
+
+// delete plans of an owner together with their share tokens
+func (redisClient *RedisClient) deletePlans(owner string, planIds []string) error {
+	shareTokens := make([]string, 0)
+	for _, planId := range planIds {
+		tokens, err := redisClient.client.SMembers(planShareTokensRedisKey(planId)).Result()
+		if err != nil {
+			return err
+		}
+		shareTokens = append(shareTokens, tokens...)
+	}
+
+	pipeline := redisClient.client.TxPipeline()
+	for _, planId := range planIds {
+		pipeline.Del(planRedisKey(planId))
+		pipeline.ZRem(userPlansRedisKey(owner), planId)
+		pipeline.Del(planShareTokensRedisKey(planId))
+	}
+	// revoke all share tokens of the plans
+	for _, token := range shareTokens {
+		pipeline.Del(planShareTokenRedisKey(token))
+	}
+	_, err := pipeline.Exec()
+	return err
+}
+
+// mint an unguessable read-only share token for a plan
+// the token never expires if expiration is zero
+func (redisClient *RedisClient) CreatePlanShareToken(planId string, expiration time.Duration) (token string, err error) {
+	token, err = utils.GenerateRandomToken(planShareTokenNumBytes)
+	if err != nil {
+		return
+	}
+
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.Set(planShareTokenRedisKey(token), planId, expiration)
+	pipeline.SAdd(planShareTokensRedisKey(planId), token)
+	_, err = pipeline.Exec()
+	return
+}
+
+// find the plan shared with a token, expired or revoked tokens are not found
+func (redisClient *RedisClient) GetSharedPlanId(token string) (planId string, err error) {
+	planId, err = redisClient.client.Get(planShareTokenRedisKey(token)).Result()
+	if err == redis.Nil {
+		err = errors.New("share token does not exist")
+	}
+	return
+}
+
+// revoke a share token of a plan
+func (redisClient *RedisClient) RevokePlanShareToken(planId string, token string) (err error) {
+	sharedPlanId, err := redisClient.GetSharedPlanId(token)
+	if err != nil {
+		return
+	}
+	if sharedPlanId != planId {
+		return errors.New("share token does not belong to the plan")
+	}
+
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.Del(planShareTokenRedisKey(token))
+	pipeline.SRem(planShareTokensRedisKey(planId), token)
+	_, err = pipeline.Exec()
+	return
+}
+
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func toPlanRecord(planId string, planData map[string]string) PlanRecord {
+	createdAt, _ := time.Parse(time.RFC3339, planData["created_at"])
+	return PlanRecord{
+		ID:        planId,
+		Owner:     planData["owner"],
+		Title:     planData["title"],
+		CreatedAt: createdAt,
+	}
+}
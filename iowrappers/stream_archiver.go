@@ -0,0 +1,330 @@
+package iowrappers
+
+import (
+	"fmt"
+	"github.com/go-redis/redis/v7"
+	log "github.com/sirupsen/logrus"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultArchiverGroup     = "planning_events_archiver"
+	DefaultArchiverBatchSize = 100
+	DefaultArchiverBlockTime = 5 * time.Second
+	DefaultArchiverMinIdle   = time.Minute
+	// planning events stay in the stream for the analytics after they are archived
+	DefaultStreamRetention = 30 * 24 * time.Hour
+	archiverTrimInterval   = time.Minute
+	archiverRetryInterval  = 5 * time.Second
+)
+
+// durable storage of planning events, e.g. MongoDB
+type PlanningEventArchive interface {
+	ArchivePlanningEvents(entries []PlanningStreamEntry) error
+}
+
+// consumer of the planning API usage stream in a consumer group
+// events are acknowledged after they are archived, events of crashed consumers are reclaimed after MinIdle
+// archived events older than Retention are removed from the stream
+type StreamArchiver struct {
+	RedisClient *RedisClient
+	Archive     PlanningEventArchive
+	Stream      string
+	Group       string
+	Consumer    string
+	BatchSize   int64
+	BlockTime   time.Duration
+	MinIdle     time.Duration
+	Retention   time.Duration
+}
+
+func (archiver *StreamArchiver) setDefaults() {
+	if archiver.Group == "" {
+		archiver.Group = DefaultArchiverGroup
+	}
+	if archiver.Consumer == "" {
+		// consumer names must be stable across restarts to read pending events again
+		archiver.Consumer, _ = os.Hostname()
+		if archiver.Consumer == "" {
+			archiver.Consumer = "archiver"
+		}
+	}
+	if archiver.BatchSize <= 0 {
+		archiver.BatchSize = DefaultArchiverBatchSize
+	}
+	if archiver.BlockTime <= 0 {
+		archiver.BlockTime = DefaultArchiverBlockTime
+	}
+	if archiver.MinIdle <= 0 {
+		archiver.MinIdle = DefaultArchiverMinIdle
+	}
+	if archiver.Retention <= 0 {
+		archiver.Retention = DefaultStreamRetention
+	}
+}
+
+// create the consumer group, existing events are archived as well
+func (archiver *StreamArchiver) createGroup() error {
+	err := archiver.RedisClient.client.XGroupCreateMkStream(archiver.Stream, archiver.Group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// archive events until the stop channel is closed
+func (archiver *StreamArchiver) Run(stop <-chan struct{}) {
+	archiver.setDefaults()
+	for {
+		if err := archiver.createGroup(); err == nil {
+			break
+		} else {
+			log.Errorf("failed to create consumer group %s: %v", archiver.Group, err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(archiverRetryInterval):
+		}
+	}
+
+	// events delivered to this consumer before a restart
+	for {
+		numArchived, err := archiver.ArchiveBatch("0")
+		if utils.CheckErrImmediate(err, utils.LogError) || numArchived == 0 {
+			break
+		}
+	}
+
+	lastTrimTime := time.Time{}
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		if time.Since(lastTrimTime) >= archiverTrimInterval {
+			if _, err := archiver.ReclaimPending(); err != nil {
+				log.Error(err)
+			}
+			if _, err := archiver.Trim(time.Now()); err != nil {
+				log.Error(err)
+			}
+			lastTrimTime = time.Now()
+		}
+
+		if _, err := archiver.ArchiveBatch(">"); err != nil {
+			log.Error(err)
+			select {
+			case <-stop:
+				return
+			case <-time.After(archiverRetryInterval):
+			}
+		}
+	}
+}
+
+// read a batch of events with XREADGROUP from the ID, archive and acknowledge them
+// ID ">" reads new events and ID "0" reads events delivered to the consumer but not acknowledged
+func (archiver *StreamArchiver) ArchiveBatch(id string) (numArchived int, err error) {
+	args := &redis.XReadGroupArgs{
+		Group:    archiver.Group,
+		Consumer: archiver.Consumer,
+		Streams:  []string{archiver.Stream, id},
+		Count:    archiver.BatchSize,
+		Block:    archiver.BlockTime,
+	}
+	if id != ">" {
+		args.Block = -1 // pending events are returned immediately
+	}
+	streams, err := archiver.RedisClient.client.XReadGroup(args).Result()
+	if err == redis.Nil { // no new events before timeout
+		return 0, nil
+	}
+	if err != nil {
+		return
+	}
+
+	for _, stream := range streams {
+		if err = archiver.archive(stream.Messages); err != nil {
+			return
+		}
+		numArchived += len(stream.Messages)
+	}
+	return
+}
+
+// claim events delivered to other consumers and not acknowledged for MinIdle, e.g. when the consumers crash
+func (archiver *StreamArchiver) ReclaimPending() (numArchived int, err error) {
+	for {
+		pending, pendingErr := archiver.RedisClient.client.XPendingExt(&redis.XPendingExtArgs{
+			Stream: archiver.Stream,
+			Group:  archiver.Group,
+			Start:  "-",
+			End:    "+",
+			Count:  archiver.BatchSize,
+		}).Result()
+		if pendingErr != nil {
+			return numArchived, pendingErr
+		}
+
+		ids := make([]string, 0)
+		for _, entry := range pending {
+			if entry.Idle >= archiver.MinIdle {
+				ids = append(ids, entry.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return
+		}
+
+		messages, claimErr := archiver.RedisClient.client.XClaim(&redis.XClaimArgs{
+			Stream:   archiver.Stream,
+			Group:    archiver.Group,
+			Consumer: archiver.Consumer,
+			MinIdle:  archiver.MinIdle,
+			Messages: ids,
+		}).Result()
+		if claimErr != nil {
+			return numArchived, claimErr
+		}
+		if err = archiver.archive(messages); err != nil {
+			return
+		}
+		numArchived += len(messages)
+		// events that are claimed by other consumers in the meantime are not idle anymore
+		if len(messages) == 0 || len(pending) < int(archiver.BatchSize) {
+			return
+		}
+	}
+}
+
+func (archiver *StreamArchiver) archive(messages []redis.XMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	entries := make([]PlanningStreamEntry, len(messages))
+	ids := make([]string, len(messages))
+	for idx, message := range messages {
+		entries[idx] = toPlanningStreamEntry(message.ID, message.Values)
+		ids[idx] = message.ID
+	}
+	if err := archiver.Archive.ArchivePlanningEvents(entries); err != nil {
+		return fmt.Errorf("failed to archive %d planning events: %v", len(entries), err)
+	}
+	return archiver.RedisClient.client.XAck(archiver.Stream, archiver.Group, ids...).Err()
+}
+
+// remove events older than the retention period from the stream, only archived events are removed
+// archived events are the events up to the last delivered event of the group that are not pending
+func (archiver *StreamArchiver) Trim(now time.Time) (numRemoved int64, err error) {
+	client := archiver.RedisClient.client
+	cutoffId := strconv.FormatInt(toMilliseconds(now.Add(-archiver.Retention)), 10)
+
+	lastDeliveredId, err := archiver.lastDeliveredId()
+	if err != nil {
+		return
+	}
+	if compareStreamIds(lastDeliveredId, cutoffId) < 0 {
+		cutoffId = lastDeliveredId
+	}
+
+	pending, err := client.XPending(archiver.Stream, archiver.Group).Result()
+	if err != nil {
+		return
+	}
+	if pending.Count > 0 && compareStreamIds(pending.Lower, cutoffId) <= 0 {
+		cutoffId = previousStreamId(pending.Lower)
+		if cutoffId == "" {
+			return
+		}
+	}
+
+	for {
+		messages, rangeErr := client.XRangeN(archiver.Stream, "-", cutoffId, archiver.BatchSize).Result()
+		if rangeErr != nil {
+			return numRemoved, rangeErr
+		}
+		if len(messages) == 0 {
+			return
+		}
+		ids := make([]string, len(messages))
+		for idx, message := range messages {
+			ids[idx] = message.ID
+		}
+		removed, delErr := client.XDel(archiver.Stream, ids...).Result()
+		if delErr != nil {
+			return numRemoved, delErr
+		}
+		numRemoved += removed
+	}
+}
+
+// XINFO GROUPS is not supported by the client, the reply is an array of field-value arrays
+func (archiver *StreamArchiver) lastDeliveredId() (string, error) {
+	reply, err := archiver.RedisClient.client.Do("XINFO", "GROUPS", archiver.Stream).Result()
+	if err != nil {
+		return "", err
+	}
+	groups, _ := reply.([]interface{})
+	for _, group := range groups {
+		fields, _ := group.([]interface{})
+		info := make(map[string]interface{})
+		for idx := 0; idx+1 < len(fields); idx += 2 {
+			if field, ok := fields[idx].(string); ok {
+				info[field] = fields[idx+1]
+			}
+		}
+		if info["name"] == archiver.Group {
+			if id, ok := info["last-delivered-id"].(string); ok {
+				return id, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("consumer group %s does not exist", archiver.Group)
+}
+
+func parseStreamId(id string) (milliseconds uint64, sequence uint64) {
+	fields := strings.SplitN(id, "-", 2)
+	milliseconds, _ = strconv.ParseUint(fields[0], 10, 64)
+	if len(fields) == 2 {
+		sequence, _ = strconv.ParseUint(fields[1], 10, 64)
+	} else {
+		// an ID without sequence number in an end of range includes all the sequence numbers
+		sequence = ^uint64(0)
+	}
+	return
+}
+
+func compareStreamIds(a string, b string) int {
+	aMilliseconds, aSequence := parseStreamId(a)
+	bMilliseconds, bSequence := parseStreamId(b)
+	switch {
+	case aMilliseconds < bMilliseconds:
+		return -1
+	case aMilliseconds > bMilliseconds:
+		return 1
+	case aSequence < bSequence:
+		return -1
+	case aSequence > bSequence:
+		return 1
+	}
+	return 0
+}
+
+// the largest stream ID before an ID, empty if there is none
+func previousStreamId(id string) string {
+	milliseconds, sequence := parseStreamId(id)
+	if sequence > 0 {
+		return strconv.FormatUint(milliseconds, 10) + "-" + strconv.FormatUint(sequence-1, 10)
+	}
+	if milliseconds > 0 {
+		return strconv.FormatUint(milliseconds-1, 10)
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package iowrappers
+
+import (
+	"context"
+	"errors"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"googlemaps.github.io/maps"
+	"strings"
+	"time"
+)
+
+const TimeZoneRedisKey = "timezone:cities"
+
+// find the IANA time zone ID, e.g. "America/Los_Angeles", of a location
+func (mapsClient MapsClient) GetTimeZone(lat float64, lng float64) (timeZoneId string, err error) {
+	req := &maps.TimezoneRequest{
+		Location:  &maps.LatLng{Lat: lat, Lng: lng},
+		Timestamp: time.Now(),
+	}
+
+	resp, err := mapsClient.client.Timezone(context.Background(), req)
+	countMapsAPICall(MapsCallTimezone, err)
+	if err != nil {
+		utils.CheckErrImmediate(err, utils.LogError)
+		return
+	}
+
+	if resp == nil || resp.TimeZoneID == "" {
+		err = errors.New("maps time zone response invalid")
+		utils.CheckErrImmediate(err, utils.LogError)
+		return
+	}
+	timeZoneId = resp.TimeZoneID
+	return
+}
+
+func (redisClient *RedisClient) GetTimeZone(query GeocodeQuery) (string, error) {
+	redisField := strings.ToLower(strings.Join([]string{query.City, query.Country}, "_"))
+	return redisClient.client.HGet(TimeZoneRedisKey, redisField).Result()
+}
+
+func (redisClient *RedisClient) SetTimeZone(query GeocodeQuery, timeZoneId string) error {
+	redisField := strings.ToLower(strings.Join([]string{query.City, query.Country}, "_"))
+	_, err := redisClient.client.HSet(TimeZoneRedisKey, redisField, timeZoneId).Result()
+	return err
+}
+
+// time zones do not change for a city, use Redis before calling external time zone API
+func (poiSearcher *PoiSearcher) GetTimeZone(query *GeocodeQuery) (timeZoneId string, err error) {
+	lat, lng, err := poiSearcher.GetGeocode(query)
+	if err != nil {
+		return
+	}
+
+	// geocode query has the corrected location name
+	timeZoneId, cacheErr := poiSearcher.redisClient.GetTimeZone(*query)
+	if cacheErr == nil {
+		return
+	}
+
+	timeZoneId, err = poiSearcher.mapsClient.GetTimeZone(lat, lng)
+	if err != nil {
+		return
+	}
+	utils.CheckErrImmediate(poiSearcher.redisClient.SetTimeZone(*query, timeZoneId), utils.LogError)
+	return
+}
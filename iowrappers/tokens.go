@@ -0,0 +1,143 @@
+package iowrappers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-redis/redis/v7"
+	"github.com/weihesdlegend/Vacation-planner/user"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	RevokedTokenKeyPrefix    = "revoked_token"
+	TokenGenerationKeyPrefix = "token_generation"
+	tokenIdNumBytes          = 16
+)
+
+func jwtSigningKey() []byte {
+	return []byte(os.Getenv("JWT_SIGNING_SECRET"))
+}
+
+// issue a signed JWT of the token type for an user
+// the generation is the current token generation of the user
+func IssueToken(username string, tokenType string, generation int64, now time.Time) (token string, expiresAt time.Time, err error) {
+	switch tokenType {
+	case user.AccessToken:
+		expiresAt = now.Add(user.AccessTokenExpirationTime)
+	case user.RefreshToken:
+		expiresAt = now.Add(user.RefreshTokenExpirationTime)
+	default:
+		err = fmt.Errorf("unknown token type %s", tokenType)
+		return
+	}
+
+	tokenId, err := utils.GenerateRandomToken(tokenIdNumBytes)
+	if err != nil {
+		return
+	}
+
+	claims := user.Claims{
+		Username:   username,
+		TokenType:  tokenType,
+		Generation: generation,
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenId,
+			Subject:   username,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSigningKey())
+	return
+}
+
+// issue an access token and a refresh token for an user
+func IssueTokenPair(username string, generation int64) (tokens user.TokenPair, err error) {
+	now := time.Now()
+	tokens.AccessToken, tokens.AccessTokenExpiresAt, err = IssueToken(username, user.AccessToken, generation, now)
+	if err != nil {
+		return
+	}
+	tokens.RefreshToken, tokens.RefreshTokenExpiresAt, err = IssueToken(username, user.RefreshToken, generation, now)
+	return
+}
+
+// verify the signature, the signing algorithm, the expiration time and the type of a token
+func ParseToken(token string, tokenType string) (*user.Claims, error) {
+	claims := &user.Claims{}
+	parsedToken, err := jwt.ParseWithClaims(token, claims, func(tkn *jwt.Token) (interface{}, error) {
+		// only tokens signed by the server are accepted, e.g. tokens with the none algorithm are rejected
+		if tkn.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", tkn.Header["alg"])
+		}
+		return jwtSigningKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !parsedToken.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if claims.TokenType != tokenType {
+		return nil, fmt.Errorf("expected %s token, got %s token", tokenType, claims.TokenType)
+	}
+	return claims, nil
+}
+
+func revokedTokenRedisKey(tokenId string) string {
+	return strings.Join([]string{RevokedTokenKeyPrefix, tokenId}, ":")
+}
+
+// parse a token and check that the token is not revoked and belongs to the current token generation of the user
+func (redisClient *RedisClient) ValidateToken(token string, tokenType string) (*user.Claims, error) {
+	claims, err := ParseToken(token, tokenType)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := redisClient.client.Exists(revokedTokenRedisKey(claims.Id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if revoked > 0 {
+		return nil, errors.New("token has been revoked")
+	}
+	generation, err := redisClient.TokenGeneration(claims.Username)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Generation != generation {
+		return nil, errors.New("token has been invalidated")
+	}
+	return claims, nil
+}
+
+func tokenGenerationRedisKey(username string) string {
+	return strings.Join([]string{TokenGenerationKeyPrefix, username}, ":")
+}
+
+// tokens are issued with the current token generation of the user, which is zero until tokens are invalidated
+func (redisClient *RedisClient) TokenGeneration(username string) (int64, error) {
+	generation, err := redisClient.client.Get(tokenGenerationRedisKey(username)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return generation, err
+}
+
+// invalidate all access tokens and refresh tokens issued to an user so far
+func (redisClient *RedisClient) InvalidateTokens(username string) error {
+	return redisClient.client.Incr(tokenGenerationRedisKey(username)).Err()
+}
+
+// revoked token IDs are kept until the tokens expire
+func (redisClient *RedisClient) RevokeToken(claims *user.Claims) error {
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+	return redisClient.client.Set(revokedTokenRedisKey(claims.Id), claims.Username, ttl).Err()
+}
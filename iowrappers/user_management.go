@@ -0,0 +1,119 @@
+package iowrappers
+
+import (
+	"errors"
+	"github.com/weihesdlegend/Vacation-planner/user"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const userScanCount = 100
+
+func userRedisKey(username string) string {
+	return strings.Join([]string{UserKeyPrefix, username}, ":")
+}
+
+// list all users ordered by username, passwords are not returned
+// user keys are found with SCAN, usernames may contain colons
+func (redisClient *RedisClient) ListUsers() (users []user.User, err error) {
+	usernames := make([]string, 0)
+	var cursor uint64
+	for {
+		var keys []string
+		keys, cursor, err = redisClient.client.Scan(cursor, UserKeyPrefix+":*", userScanCount).Result()
+		if err != nil {
+			return
+		}
+		for _, key := range keys {
+			usernames = append(usernames, strings.TrimPrefix(key, UserKeyPrefix+":"))
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+	sort.Strings(usernames)
+
+	users = make([]user.User, 0, len(usernames))
+	for _, username := range usernames {
+		usr, findErr := redisClient.FindUser(username)
+		if findErr != nil { // deleted during listing
+			continue
+		}
+		usr.Password = ""
+		users = append(users, usr)
+	}
+	return
+}
+
+// change the level of an user
+func (redisClient *RedisClient) SetUserLevel(username string, level string) error {
+	if level != user.LevelAdmin && level != user.LevelRegular {
+		return errors.New("invalid user level " + level)
+	}
+	if redisClient.client.Exists(userRedisKey(username)).Val() == 0 {
+		return errors.New("user does not exist")
+	}
+	return redisClient.client.HSet(userRedisKey(username), "user_level", level).Err()
+}
+
+// disable or enable an user, disabled users cannot login or use their tokens and API keys
+func (redisClient *RedisClient) SetUserDisabled(username string, disabled bool) error {
+	if redisClient.client.Exists(userRedisKey(username)).Val() == 0 {
+		return errors.New("user does not exist")
+	}
+	return redisClient.client.HSet(userRedisKey(username), "disabled", strconv.FormatBool(disabled)).Err()
+}
+
+// check that an user exists and is not disabled
+func (redisClient *RedisClient) CheckUserActive(username string) error {
+	values, err := redisClient.client.HMGet(userRedisKey(username), "username", "disabled").Result()
+	if err != nil {
+		return err
+	}
+	if values[0] == nil {
+		return errors.New("user does not exist")
+	}
+	if disabled, _ := values[1].(string); disabled == "true" {
+		return errors.New("user is disabled")
+	}
+	return nil
+}
+
+// remove an user and the data of the user, including saved plans, API keys and day templates
+// tokens of the user are invalidated, the token generation is kept for users signing up later with the same username
+func (redisClient *RedisClient) DeleteUser(username string) error {
+	usr, err := redisClient.FindUser(username)
+	if err != nil {
+		return err
+	}
+
+	planIds, err := redisClient.client.ZRange(userPlansRedisKey(username), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, planId := range planIds {
+		if err = redisClient.DeletePlan(planId); err != nil {
+			return err
+		}
+	}
+
+	if err = redisClient.RevokeAPIKeys(username); err != nil {
+		return err
+	}
+
+	pipeline := redisClient.client.TxPipeline()
+	if usr.Email != "" {
+		// the email index is only removed if it points to the user
+		emailKey := userEmailRedisKey(usr.Email)
+		if owner, _ := redisClient.client.Get(emailKey).Result(); owner == username {
+			pipeline.Del(emailKey)
+		}
+	}
+	pipeline.Del(userPlansRedisKey(username), dayTemplatesRedisKey(username), userAPIKeysRedisKey(username))
+	pipeline.SRem(RateLimitExemptUsersRedisKey, username)
+	pipeline.Incr(tokenGenerationRedisKey(username))
+	pipeline.Del(userRedisKey(username))
+	_, err = pipeline.Exec()
+	return err
+}
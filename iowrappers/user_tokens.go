@@ -0,0 +1,67 @@
+package iowrappers
+
+import (
+	"errors"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	UserTokenKeyPrefix       = "user_token"
+	EmailVerificationToken   = "email_verification"
+	PasswordResetToken       = "password_reset"
+	EmailVerificationTimeout = time.Hour * 24
+	PasswordResetTimeout     = time.Hour
+	userTokenNumBytes        = 32
+)
+
+// one-time tokens are stored with their hashes, the values are usernames and token generations of the users
+func userTokenRedisKey(purpose string, token string) string {
+	return strings.Join([]string{UserTokenKeyPrefix, purpose, hashSecret(token)}, ":")
+}
+
+// create a one-time token of the purpose for an user, the token expires after the timeout
+func (redisClient *RedisClient) CreateUserToken(purpose string, username string, timeout time.Duration) (token string, err error) {
+	generation, err := redisClient.TokenGeneration(username)
+	if err != nil {
+		return
+	}
+	token, err = utils.GenerateRandomToken(userTokenNumBytes)
+	if err != nil {
+		return
+	}
+	redisKey := userTokenRedisKey(purpose, token)
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.HMSet(redisKey, map[string]interface{}{"username": username, "generation": generation})
+	pipeline.Expire(redisKey, timeout)
+	_, err = pipeline.Exec()
+	return
+}
+
+// find the user of a one-time token and delete the token, so that the token cannot be used again
+// tokens are invalid after the token generation of the user changes, e.g. after the user is deleted
+func (redisClient *RedisClient) ConsumeUserToken(purpose string, token string) (username string, err error) {
+	redisKey := userTokenRedisKey(purpose, token)
+	pipeline := redisClient.client.TxPipeline()
+	getCmd := pipeline.HGetAll(redisKey)
+	pipeline.Del(redisKey)
+	if _, err = pipeline.Exec(); err != nil {
+		return
+	}
+	values := getCmd.Val()
+	username = values["username"]
+	if username == "" {
+		return "", errors.New("token is invalid or has expired")
+	}
+
+	generation, err := redisClient.TokenGeneration(username)
+	if err != nil {
+		return "", err
+	}
+	if values["generation"] != strconv.FormatInt(generation, 10) {
+		return "", errors.New("token is invalid or has expired")
+	}
+	return
+}
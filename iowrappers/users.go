@@ -2,15 +2,21 @@ package iowrappers
 
 import (
 	"errors"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/weihesdlegend/Vacation-planner/user"
 	"golang.org/x/crypto/bcrypt"
-	"os"
+	"strconv"
 	"strings"
-	"time"
 )
 
-const UserKeyPrefix = "user"
+const (
+	UserKeyPrefix      = "user"
+	UserEmailKeyPrefix = "user_email"
+)
+
+// email addresses are unique among users, the index maps lowercase addresses to usernames
+func userEmailRedisKey(email string) string {
+	return strings.Join([]string{UserEmailKeyPrefix, strings.ToLower(strings.TrimSpace(email))}, ":")
+}
 
 // lookup an user
 func (redisClient *RedisClient) FindUser(username string) (user.User, error) {
@@ -25,9 +31,20 @@ func (redisClient *RedisClient) FindUser(username string) (user.User, error) {
 	usr.Email = u["email"]
 	usr.UserLevel = u["user_level"]
 	usr.Password = u["password"]
+	usr.EmailVerified = u["email_verified"] == "true"
+	usr.Disabled = u["disabled"] == "true"
 	return usr, nil
 }
 
+// lookup an user by email address
+func (redisClient *RedisClient) FindUserByEmail(email string) (user.User, error) {
+	username, err := redisClient.client.Get(userEmailRedisKey(email)).Result()
+	if err != nil {
+		return user.User{Username: "guest"}, errors.New("user does not exist")
+	}
+	return redisClient.FindUser(username)
+}
+
 // create a new user
 func (redisClient *RedisClient) CreateUser(usr user.User) error {
 	redisKey := strings.Join([]string{UserKeyPrefix, usr.Username}, ":")
@@ -40,39 +57,72 @@ func (redisClient *RedisClient) CreateUser(usr user.User) error {
 		usr.UserLevel = user.LevelRegular
 	}
 
+	if usr.Email != "" {
+		emailTaken, err := redisClient.client.SetNX(userEmailRedisKey(usr.Email), usr.Username, 0).Result()
+		if err != nil {
+			return err
+		}
+		if !emailTaken {
+			return errors.New("email is already in use")
+		}
+	}
+
 	userData := map[string]interface{}{
-		"username":   usr.Username,
-		"user_level": usr.UserLevel,
-		"password":   string(psw),
-		"email":      usr.Email,
+		"username":       usr.Username,
+		"user_level":     usr.UserLevel,
+		"password":       string(psw),
+		"email":          usr.Email,
+		"email_verified": strconv.FormatBool(usr.EmailVerified),
 	}
-	_, err := redisClient.client.HMSet(redisKey, userData).Result()
+	// tokens issued to an earlier user with the same username are not valid for the new user
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.Incr(tokenGenerationRedisKey(usr.Username))
+	pipeline.HMSet(redisKey, userData)
+	_, err := pipeline.Exec()
 	return err
 }
 
+// mark the email address of an user as verified
+func (redisClient *RedisClient) SetEmailVerified(username string) error {
+	redisKey := strings.Join([]string{UserKeyPrefix, username}, ":")
+	if redisClient.client.Exists(redisKey).Val() == 0 {
+		return errors.New("user does not exist")
+	}
+	return redisClient.client.HSet(redisKey, "email_verified", strconv.FormatBool(true)).Err()
+}
+
+// replace the password of an user
+func (redisClient *RedisClient) UpdatePassword(username string, password string) error {
+	redisKey := strings.Join([]string{UserKeyPrefix, username}, ":")
+	if redisClient.client.Exists(redisKey).Val() == 0 {
+		return errors.New("user does not exist")
+	}
+	psw, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return redisClient.client.HSet(redisKey, "password", string(psw)).Err()
+}
+
 // authenticate an user when a new user that holds no JWT or an existing user with expired JWT
-func (redisClient *RedisClient) Authenticate(credential user.Credential) (string, time.Time, error) {
+func (redisClient *RedisClient) Authenticate(credential user.Credential) (user.TokenPair, error) {
 	u, err := redisClient.FindUser(credential.Username)
 	if err != nil {
-		return "", time.Now(), err
+		return user.TokenPair{}, err
 	}
 
 	pswCompErr := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(credential.Password))
 	if pswCompErr != nil { // wrong password
 		err = errors.New("wrong password")
-		return "", time.Now(), err
+		return user.TokenPair{}, err
+	}
+	if u.Disabled {
+		return user.TokenPair{}, errors.New("user is disabled")
 	}
 
-	lastLoginTime := time.Now()
-	tokenExpirationTime := lastLoginTime.Add(user.JWTExpirationTime)
-	expiresAt := tokenExpirationTime.Unix() // expires after 10 days
-	jwtSigningSecret := os.Getenv("JWT_SIGNING_SECRET")
-
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username":       u.Username,
-		"StandardClaims": jwt.StandardClaims{ExpiresAt: expiresAt},
-	})
-
-	token, jwtSignErr := jwtToken.SignedString([]byte(jwtSigningSecret))
-	return token, tokenExpirationTime, jwtSignErr
+	generation, err := redisClient.TokenGeneration(u.Username)
+	if err != nil {
+		return user.TokenPair{}, err
+	}
+	return IssueTokenPair(u.Username, generation)
 }
@@ -0,0 +1,31 @@
+package iowrappers
+
+import (
+	"strings"
+)
+
+// destinations warmed up in the current run, the set expires with the cached slot solutions
+var WarmUpCompletedRedisKey = strings.Join([]string{"warm_up", "completed"}, ":")
+
+func (redisClient *RedisClient) IsWarmUpCompleted(destination string) (bool, error) {
+	return redisClient.client.SIsMember(WarmUpCompletedRedisKey, destination).Result()
+}
+
+// a new run starts when the set has no expiration
+func (redisClient *RedisClient) SetWarmUpCompleted(destination string) error {
+	pipeline := redisClient.client.TxPipeline()
+	pipeline.SAdd(WarmUpCompletedRedisKey, destination)
+	ttlCmd := pipeline.TTL(WarmUpCompletedRedisKey)
+	if _, err := pipeline.Exec(); err != nil {
+		return err
+	}
+	if ttlCmd.Val() < 0 {
+		return redisClient.client.Expire(WarmUpCompletedRedisKey, SlotSolutionExpirationTime).Err()
+	}
+	return nil
+}
+
+// forget the progress of the current run, all destinations are warmed up again
+func (redisClient *RedisClient) ResetWarmUp() error {
+	return redisClient.client.Del(WarmUpCompletedRedisKey).Err()
+}
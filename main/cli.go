@@ -0,0 +1,558 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const OutputJSON = "json"
+
+const cliUsage = `usage: vacation-planner [command] [flags]
+
+commands:
+  serve     start the REST server, the default command
+  plan      plan a day in a city
+  geocode   find the latitude and longitude of a city
+  nearby    search places around a city
+  warmup    pre-populate the caches for a list of destinations
+  replay    replay captured planning requests and compare the results with a baseline
+
+run "vacation-planner <command> -h" for the flags of a command
+`
+
+// services used by the commands, created from the same configuration as the server
+type cliServices struct {
+	config      planner.PlannerConfig
+	redisClient iowrappers.RedisClient
+	poiSearcher *iowrappers.PoiSearcher
+	solver      *solution.Solver
+}
+
+type GeocodeOutput struct {
+	City      string  `json:"city"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type ReplayOutput struct {
+	Report planner.ReplayReport `json:"report"`
+	Diff   *planner.ReplayDiff  `json:"diff,omitempty"`
+}
+
+type NearbyPlaceOutput struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Rating     float32 `json:"rating"`
+	PriceLevel int     `json:"price_level"`
+	Address    string  `json:"address"`
+	URL        string  `json:"url"`
+}
+
+// run a command with its arguments, returns the exit code
+func RunCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	commands := map[string]func([]string, io.Writer, io.Writer) error{
+		"plan":    planCommand,
+		"geocode": geocodeCommand,
+		"nearby":  nearbyCommand,
+		"warmup":  warmUpCommand,
+		"replay":  replayCommand,
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, cliUsage)
+		return 0
+	}
+	command, exist := commands[args[0]]
+	if !exist {
+		fmt.Fprintf(stderr, "unknown command %s\n\n%s", args[0], cliUsage)
+		return 2
+	}
+	err := command(args[1:], stdout, stderr)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func newCLIServices() (services *cliServices, err error) {
+	conf, err := LoadConfig()
+	if err != nil {
+		return
+	}
+	redisURL, err := url.Parse(conf.Redis.RedisUrl)
+	if err != nil {
+		return
+	}
+
+	services = &cliServices{config: conf.PlannerConfig()}
+	services.redisClient = iowrappers.CreateRedisClient(redisURL)
+	services.poiSearcher = &iowrappers.PoiSearcher{}
+	services.poiSearcher.Init(conf.MapsClientApiKey, redisURL, services.config.PoiSearcher)
+	services.solver = &solution.Solver{}
+	services.solver.Init(services.poiSearcher, services.config.Solver)
+	return
+}
+
+func (services *cliServices) Destroy() {
+	iowrappers.DestroyLogger()
+	services.redisClient.Destroy()
+}
+
+func newFlagSet(name string, stderr io.Writer) (flags *flag.FlagSet, city *string, country *string, output *string) {
+	flags = flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	city = flags.String("city", "", "city name, required")
+	country = flags.String("country", "", "country name, required")
+	output = flags.String("output", planner.TextFormatTable, "output format: table, json or markdown")
+	return
+}
+
+func parseFlags(flags *flag.FlagSet, args []string, city *string, country *string, output *string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*city) == "" || strings.TrimSpace(*country) == "" {
+		return errors.New("city and country are required")
+	}
+	switch *output {
+	case planner.TextFormatTable, planner.TextFormatMarkdown, OutputJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %s, valid formats are table, json and markdown", *output)
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// plan a day with the same slot requests as the planning APIs
+// plans are not saved and planning events are not logged
+func planCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, city, country, output := newFlagSet("plan", stderr)
+	date := flags.String("date", time.Now().Format(POI.DateLayout), "date in the format of YYYY-MM-DD")
+	startTime := flags.String("start", "09:00", "start time in the format of HH:MM")
+	endTime := flags.String("end", "22:00", "end time in the format of HH:MM")
+	numVisit := flags.Uint("visits", 2, "number of places to visit")
+	numEatery := flags.Uint("eateries", 1, "number of eateries")
+	numPlans := flags.Int("plans", 1, "number of plan options to print")
+	if err := parseFlags(flags, args, city, country, output); err != nil {
+		return err
+	}
+
+	req := planner.PlanningPostRequest{
+		Country:   *country,
+		City:      *city,
+		Date:      *date,
+		NumVisit:  *numVisit,
+		NumEatery: *numEatery,
+	}
+	var err error
+	if req.StartTime, err = POI.ParseTimeOfDay(*startTime); err != nil {
+		return err
+	}
+	if req.EndTime, err = POI.ParseTimeOfDay(*endTime); err != nil {
+		return err
+	}
+
+	services, err := newCLIServices()
+	if err != nil {
+		return err
+	}
+	defer services.Destroy()
+
+	planningReq, err := planner.ProcessPlanningPostRequest(&req, services.config)
+	if err != nil {
+		return err
+	}
+
+	resp := planner.PlanningResponse{}
+	if timeZone, timeZoneErr := services.solver.GetTimeZone(planningReq.SlotRequests[0].Location); timeZoneErr == nil {
+		resp.TimeZone = timeZone
+	}
+	if err = planner.ValidatePlanningDate(planningReq.Date, resp.TimeZone, time.Now()); err != nil {
+		return err
+	}
+
+	solverResp, err := services.solver.Solve(planningReq, services.redisClient)
+	if err != nil {
+		return err
+	}
+	if len(solverResp.Solutions) == 0 {
+		return errors.New("cannot find a valid solution")
+	}
+	resp.SetSolutions(&planningReq, solverResp)
+	if *numPlans > 0 && *numPlans < len(resp.Places) {
+		resp.Places = resp.Places[:*numPlans]
+	}
+
+	if *output == OutputJSON {
+		return writeJSON(stdout, resp)
+	}
+	return planner.WritePlanningText(stdout, *output, resp)
+}
+
+func geocodeCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, city, country, output := newFlagSet("geocode", stderr)
+	if err := parseFlags(flags, args, city, country, output); err != nil {
+		return err
+	}
+
+	services, err := newCLIServices()
+	if err != nil {
+		return err
+	}
+	defer services.Destroy()
+
+	query := &iowrappers.GeocodeQuery{City: *city, Country: *country}
+	lat, lng, err := services.poiSearcher.GetGeocode(query)
+	if err != nil {
+		return err
+	}
+
+	// the query may be corrected by the geocoding service
+	geocode := GeocodeOutput{City: query.City, Country: query.Country, Latitude: lat, Longitude: lng}
+	if *output == OutputJSON {
+		return writeJSON(stdout, geocode)
+	}
+	return planner.WriteTable(stdout, *output, []string{"City", "Country", "Latitude", "Longitude"}, [][]string{{
+		geocode.City, geocode.Country, strconv.FormatFloat(lat, 'f', 6, 64), strconv.FormatFloat(lng, 'f', 6, 64),
+	}})
+}
+
+func nearbyCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags, city, country, output := newFlagSet("nearby", stderr)
+	category := flags.String("category", "visit", "place category: visit or eatery")
+	radius := flags.Uint("radius", 5000, "search radius in meters")
+	limit := flags.Uint("limit", 20, "maximum number of places")
+	if err := parseFlags(flags, args, city, country, output); err != nil {
+		return err
+	}
+
+	var placeCat POI.PlaceCategory
+	switch strings.ToLower(*category) {
+	case "visit":
+		placeCat = POI.PlaceCategoryVisit
+	case "eatery":
+		placeCat = POI.PlaceCategoryEatery
+	default:
+		return fmt.Errorf("unknown category %s, valid categories are visit and eatery", *category)
+	}
+
+	services, err := newCLIServices()
+	if err != nil {
+		return err
+	}
+	defer services.Destroy()
+
+	places, err := services.poiSearcher.NearbySearch(&iowrappers.PlaceSearchRequest{
+		Location:      *city + "," + *country,
+		PlaceCat:      placeCat,
+		Radius:        *radius,
+		RankBy:        "prominence",
+		MinNumResults: *limit,
+		MaxNumResults: *limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	nearbyPlaces := make([]NearbyPlaceOutput, len(places))
+	rows := make([][]string, len(places))
+	for idx, place := range places {
+		nearbyPlaces[idx] = NearbyPlaceOutput{
+			ID:         place.GetID(),
+			Name:       place.GetName(),
+			Rating:     place.GetRating(),
+			PriceLevel: place.GetPriceLevel(),
+			Address:    place.GetFormattedAddress(),
+			URL:        place.GetURL(),
+		}
+		rows[idx] = []string{place.GetName(), strconv.FormatFloat(float64(place.GetRating()), 'f', 1, 32),
+			strconv.Itoa(place.GetPriceLevel()), place.GetFormattedAddress()}
+	}
+	if *output == OutputJSON {
+		return writeJSON(stdout, nearbyPlaces)
+	}
+	return planner.WriteTable(stdout, *output, []string{"Name", "Rating", "Price Level", "Address"}, rows)
+}
+
+// warm up the caches of destinations in a CSV file, e.g. in a scheduled job
+// an interrupted run is resumed by running the command again
+func warmUpCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("warmup", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	file := flags.String("file", "data/capitals.csv", "CSV file of destinations in the format of country,city")
+	concurrency := flags.Int("concurrency", planner.WarmUpConcurrency, "maximum number of destinations warmed up at the same time")
+	radius := flags.Uint("radius", planner.WarmUpSearchRadius, "search radius in meters")
+	weekdays := flags.String("weekdays", "0,1,2,3,4,5,6", "comma-separated weekdays of slot solutions, 0 is Monday")
+	restart := flags.Bool("restart", false, "warm up destinations completed in a previous run again")
+	output := flags.String("output", planner.TextFormatTable, "output format of the summary: table, json or markdown")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	switch *output {
+	case planner.TextFormatTable, planner.TextFormatMarkdown, OutputJSON:
+	default:
+		return fmt.Errorf("unknown output format %s, valid formats are table, json and markdown", *output)
+	}
+	if *concurrency <= 0 {
+		return errors.New("concurrency must be positive")
+	}
+
+	warmUpWeekdays := make([]POI.Weekday, 0)
+	for _, weekday := range strings.Split(*weekdays, ",") {
+		weekdayUint, err := strconv.ParseUint(strings.TrimSpace(weekday), 10, 8)
+		if err != nil || weekdayUint > 6 {
+			return fmt.Errorf("invalid weekday of %s", weekday)
+		}
+		warmUpWeekdays = append(warmUpWeekdays, POI.Weekday(weekdayUint))
+	}
+
+	rows := utils.ReadCsv(*file)
+	if len(rows) == 0 {
+		return fmt.Errorf("no destinations found in %s", *file)
+	}
+	destinations, err := planner.ParseWarmUpDestinations(rows)
+	if err != nil {
+		return fmt.Errorf("invalid destinations file %s: %s", *file, err.Error())
+	}
+
+	services, err := newCLIServices()
+	if err != nil {
+		return err
+	}
+	defer services.Destroy()
+
+	if *restart {
+		if err = services.redisClient.ResetWarmUp(); err != nil {
+			return err
+		}
+	}
+
+	warmer := planner.CacheWarmer{
+		RedisClient:  &services.redisClient,
+		PoiSearcher:  services.poiSearcher,
+		SolverConfig: services.config.Solver,
+		Concurrency:  *concurrency,
+		SearchRadius: *radius,
+		Weekdays:     warmUpWeekdays,
+	}
+	// progress is reported to stderr so that the summary can be parsed from stdout
+	summary := warmer.Run(destinations, func(destination planner.WarmUpDestination, skipped bool, err error) {
+		switch {
+		case err != nil:
+			fmt.Fprintf(stderr, "failed %s: %s\n", destination, err.Error())
+		case skipped:
+			fmt.Fprintf(stderr, "skipped %s\n", destination)
+		default:
+			fmt.Fprintf(stderr, "warmed up %s\n", destination)
+		}
+	})
+
+	if *output == OutputJSON {
+		err = writeJSON(stdout, summary)
+	} else {
+		err = planner.WriteTable(stdout, *output, []string{"Total", "Completed", "Skipped", "Failed", "Seconds"}, [][]string{{
+			strconv.Itoa(summary.Total), strconv.Itoa(summary.Completed), strconv.Itoa(summary.Skipped),
+			strconv.Itoa(summary.Failed), strconv.FormatFloat(summary.Seconds, 'f', 1, 64),
+		}})
+		if err == nil && len(summary.Failures) > 0 {
+			rows := make([][]string, len(summary.Failures))
+			for idx, failure := range summary.Failures {
+				rows[idx] = []string{failure.Destination, failure.Error}
+			}
+			fmt.Fprintln(stdout)
+			err = planner.WriteTable(stdout, *output, []string{"Destination", "Error"}, rows)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d destinations failed, run the command again to retry them", summary.Failed, summary.Total)
+	}
+	return nil
+}
+
+type replayMetric struct {
+	name  string
+	value func(report planner.ReplayReport) float64
+}
+
+var replayMetrics = []replayMetric{
+	{"requests", func(report planner.ReplayReport) float64 { return float64(report.NumRequests) }},
+	{"errors", func(report planner.ReplayReport) float64 { return float64(report.NumErrors) }},
+	{"requests without solutions", func(report planner.ReplayReport) float64 { return float64(report.NumWithoutSolutions) }},
+	{"latency p50 (ms)", func(report planner.ReplayReport) float64 { return report.LatencyMs.P50 }},
+	{"latency p90 (ms)", func(report planner.ReplayReport) float64 { return report.LatencyMs.P90 }},
+	{"latency p99 (ms)", func(report planner.ReplayReport) float64 { return report.LatencyMs.P99 }},
+	{"latency max (ms)", func(report planner.ReplayReport) float64 { return report.LatencyMs.Max }},
+	{"solutions min", func(report planner.ReplayReport) float64 { return report.NumSolutions.Min }},
+	{"solutions p50", func(report planner.ReplayReport) float64 { return report.NumSolutions.P50 }},
+	{"top score min", func(report planner.ReplayReport) float64 { return report.TopScores.Min }},
+	{"top score p50", func(report planner.ReplayReport) float64 { return report.TopScores.P50 }},
+	{"top score p90", func(report planner.ReplayReport) float64 { return report.TopScores.P90 }},
+	{"top score max", func(report planner.ReplayReport) float64 { return report.TopScores.Max }},
+	{"score p50", func(report planner.ReplayReport) float64 { return report.Scores.P50 }},
+	{"score p90", func(report planner.ReplayReport) float64 { return report.Scores.P90 }},
+}
+
+func formatMetric(value float64) string {
+	return strconv.FormatFloat(value, 'f', 3, 64)
+}
+
+// replay captured requests against the solver, e.g. before and after a scoring change
+// the command fails if any request regresses against the baseline
+func replayCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	file := flags.String("file", planner.CapturedRequestsFile, "JSONL file of captured planning requests")
+	baselineFile := flags.String("baseline", "", "report of a previous replay to compare with")
+	saveBaselineFile := flags.String("save-baseline", "", "save the report of this replay as a baseline")
+	tolerance := flags.Float64("tolerance", 0, "decrease of the top score of a request allowed before it is a regression")
+	output := flags.String("output", planner.TextFormatTable, "output format: table, json or markdown")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	switch *output {
+	case planner.TextFormatTable, planner.TextFormatMarkdown, OutputJSON:
+	default:
+		return fmt.Errorf("unknown output format %s, valid formats are table, json and markdown", *output)
+	}
+	if *tolerance < 0 {
+		return errors.New("tolerance cannot be negative")
+	}
+
+	capturedFile, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	requests, err := planner.ReadCapturedRequests(capturedFile)
+	capturedFile.Close()
+	if err != nil {
+		return fmt.Errorf("invalid captured requests file %s: %s", *file, err.Error())
+	}
+	if len(requests) == 0 {
+		return fmt.Errorf("no requests found in %s", *file)
+	}
+
+	var baseline *planner.ReplayReport
+	if *baselineFile != "" {
+		data, readErr := ioutil.ReadFile(*baselineFile)
+		if readErr != nil {
+			return readErr
+		}
+		baseline = &planner.ReplayReport{}
+		if err = json.Unmarshal(data, baseline); err != nil {
+			return fmt.Errorf("invalid baseline file %s: %s", *baselineFile, err.Error())
+		}
+	}
+
+	services, err := newCLIServices()
+	if err != nil {
+		return err
+	}
+	defer services.Destroy()
+
+	replayOutput := ReplayOutput{Report: planner.Replay(services.solver, services.redisClient, requests)}
+	if baseline != nil {
+		diff, diffErr := planner.DiffReplayReports(*baseline, replayOutput.Report, *tolerance)
+		if diffErr != nil {
+			return diffErr
+		}
+		replayOutput.Diff = &diff
+	}
+
+	if *saveBaselineFile != "" {
+		data, marshalErr := json.MarshalIndent(replayOutput.Report, "", "  ")
+		if marshalErr != nil {
+			return marshalErr
+		}
+		if err = ioutil.WriteFile(*saveBaselineFile, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	if *output == OutputJSON {
+		err = writeJSON(stdout, replayOutput)
+	} else {
+		err = writeReplayText(stdout, *output, replayOutput, baseline)
+	}
+	if err != nil {
+		return err
+	}
+	if replayOutput.Diff != nil && len(replayOutput.Diff.Regressions) > 0 {
+		return fmt.Errorf("%d of %d requests regressed against the baseline", len(replayOutput.Diff.Regressions), len(requests))
+	}
+	return nil
+}
+
+func writeReplayText(w io.Writer, format string, replayOutput ReplayOutput, baseline *planner.ReplayReport) error {
+	headers := []string{"Metric", "Value"}
+	if baseline != nil {
+		headers = []string{"Metric", "Baseline", "Current", "Change"}
+	}
+	rows := make([][]string, len(replayMetrics))
+	for idx, metric := range replayMetrics {
+		current := metric.value(replayOutput.Report)
+		rows[idx] = []string{metric.name, formatMetric(current)}
+		if baseline != nil {
+			previous := metric.value(*baseline)
+			rows[idx] = []string{metric.name, formatMetric(previous), formatMetric(current), formatMetric(current - previous)}
+		}
+	}
+	if err := planner.WriteTable(w, format, headers, rows); err != nil {
+		return err
+	}
+
+	errorRows := make([][]string, 0)
+	for _, result := range replayOutput.Report.Results {
+		if result.Error != "" {
+			errorRows = append(errorRows, []string{result.Request, result.Error})
+		}
+	}
+	if len(errorRows) > 0 {
+		fmt.Fprintln(w)
+		if err := planner.WriteTable(w, format, []string{"Request", "Error"}, errorRows); err != nil {
+			return err
+		}
+	}
+
+	diff := replayOutput.Diff
+	if diff == nil {
+		return nil
+	}
+	if len(diff.Regressions) > 0 {
+		regressionRows := make([][]string, len(diff.Regressions))
+		for idx, regression := range diff.Regressions {
+			regressionRows[idx] = []string{regression.Request, regression.Reason}
+		}
+		fmt.Fprintln(w)
+		if err := planner.WriteTable(w, format, []string{"Regressed Request", "Reason"}, regressionRows); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d improved, %d unchanged, %d regressed\n", diff.NumImproved, diff.NumUnchanged, len(diff.Regressions))
+	return err
+}
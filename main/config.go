@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/matching"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// path of the optional YAML configuration file
+const ConfigFileEnvVar = "CONFIG_FILE"
+
+// configuration values are taken from the defaults, then from the configuration file,
+// and finally from environment variables
+// defaults are set by DefaultConfig instead of default tags, so that envconfig does not overwrite values from the file
+type Config struct {
+	Server struct {
+		ServerPort string        `envconfig:"PORT" yaml:"port"`
+		Timeout    time.Duration `envconfig:"SERVER_TIMEOUT" yaml:"timeout"`  // read and write timeout
+		NumWorkers int           `envconfig:"NUM_WORKERS" yaml:"num_workers"` // workers processing planning events
+		// workers solving queued planning jobs
+		NumPlanJobWorkers int `envconfig:"NUM_PLAN_JOB_WORKERS" yaml:"num_plan_job_workers"`
+		// validate the Maps key in readiness checks, a time zone request is made at most every 10 minutes
+		ReadinessCheckMapsKey bool `envconfig:"READINESS_CHECK_MAPS_KEY" yaml:"readiness_check_maps_key"`
+		// valid planning requests are appended to the JSONL file for replay if set
+		CaptureRequestsFile string `envconfig:"CAPTURE_REQUESTS_FILE" yaml:"capture_requests_file"`
+	} `yaml:"server"`
+	Redis struct {
+		RedisUrl        string `envconfig:"REDISCLOUD_URL" yaml:"url"`
+		RedisStreamName string `yaml:"stream_name"`
+	} `yaml:"redis"`
+	RateLimit struct {
+		WindowInSeconds int              `envconfig:"RATE_LIMIT_WINDOW_IN_SECONDS" yaml:"window_in_seconds"`
+		UserLimit       int64            `envconfig:"RATE_LIMIT_USER" yaml:"user_limit"`
+		GuestLimit      int64            `envconfig:"RATE_LIMIT_GUEST" yaml:"guest_limit"`
+		EndpointLimits  map[string]int64 `envconfig:"RATE_LIMIT_ENDPOINTS" yaml:"endpoint_limits"` // e.g. "POST /v1/plans:10,GET /v1/plans:10"
+		TrustedProxies  []string         `envconfig:"TRUSTED_PROXIES" yaml:"trusted_proxies"`      // e.g. "10.0.0.0/8,192.0.2.1"
+	} `yaml:"rate_limit"`
+	Mail struct {
+		Mailer       string `envconfig:"MAILER" yaml:"mailer"` // smtp, file or log
+		Dir          string `envconfig:"MAIL_DIR" yaml:"dir"`
+		SMTPHost     string `envconfig:"SMTP_HOST" yaml:"smtp_host"`
+		SMTPPort     int    `envconfig:"SMTP_PORT" yaml:"smtp_port"`
+		SMTPUsername string `envconfig:"SMTP_USERNAME" yaml:"smtp_username"`
+		SMTPPassword string `envconfig:"SMTP_PASSWORD" yaml:"smtp_password"`
+		From         string `envconfig:"MAIL_FROM" yaml:"from"`
+		PublicURL    string `envconfig:"PUBLIC_URL" yaml:"public_url"`
+	} `yaml:"mail"`
+	Mongo struct {
+		URI    string `envconfig:"MONGODB_URI" yaml:"uri"` // planning events are archived to MongoDB if set
+		DbName string `envconfig:"MONGODB_DB_NAME" yaml:"db_name"`
+	} `yaml:"mongo"`
+	StreamArchiver struct {
+		Consumer       string `envconfig:"STREAM_ARCHIVER_CONSUMER" yaml:"consumer"`
+		RetentionHours int    `envconfig:"STREAM_RETENTION_HOURS" yaml:"retention_hours"`
+	} `yaml:"stream_archiver"`
+	Planner struct {
+		MaxPlacesPerSlot int `envconfig:"MAX_PLACES_PER_SLOT" yaml:"max_places_per_slot"`
+		MaxPlacesPerDay  int `envconfig:"MAX_PLACES_PER_DAY" yaml:"max_places_per_day"`
+	} `yaml:"planner"`
+	Solver struct {
+		TravelSpeed              float64 `envconfig:"TRAVEL_SPEED" yaml:"travel_speed"`                               // km/h
+		TimeLimitBetweenClusters uint    `envconfig:"TIME_LIMIT_BETWEEN_CLUSTERS" yaml:"time_limit_between_clusters"` // minutes
+		CandidateQueueLength     int     `envconfig:"CANDIDATE_QUEUE_LENGTH" yaml:"candidate_queue_length"`
+		TimeClusterMinResults    uint    `envconfig:"TIME_CLUSTER_MIN_RESULTS" yaml:"time_cluster_min_results"`
+		// prices of Google Maps price levels 0 to 4, and of places without a price level
+		PriceLevels       []float64 `envconfig:"PRICE_LEVELS" yaml:"price_levels"`
+		PriceLevelDefault float64   `envconfig:"PRICE_LEVEL_DEFAULT" yaml:"price_level_default"`
+	} `yaml:"solver"`
+	PoiSearcher struct {
+		MaxSearchRadius              uint          `envconfig:"MAX_SEARCH_RADIUS" yaml:"max_search_radius"` // meters
+		MinMapsResultRefreshDuration time.Duration `envconfig:"MIN_MAPS_RESULT_REFRESH_DURATION" yaml:"min_maps_result_refresh_duration"`
+	} `yaml:"poi_searcher"`
+	MapsClientApiKey string `split_words:"true" yaml:"maps_client_api_key"`
+}
+
+func DefaultConfig() Config {
+	conf := Config{}
+	conf.Server.ServerPort = "10000"
+	conf.Server.Timeout = planner.ServerTimeout
+	conf.Server.NumWorkers = 5
+	conf.Server.NumPlanJobWorkers = 3
+	conf.Redis.RedisStreamName = "stream:planning_api_usage"
+
+	rateLimits := planner.DefaultRateLimitConfig()
+	conf.RateLimit.WindowInSeconds = int(rateLimits.Window.Seconds())
+	conf.RateLimit.UserLimit = rateLimits.UserLimit
+	conf.RateLimit.GuestLimit = rateLimits.GuestLimit
+
+	conf.Mail.Mailer = iowrappers.MailerLog
+	conf.Mail.Dir = "mails"
+	conf.Mail.SMTPPort = 587
+	conf.Mail.From = "no-reply@unwind.dev"
+	conf.Mail.PublicURL = "http://localhost:10000"
+
+	conf.Mongo.DbName = "VacationPlanner"
+	conf.StreamArchiver.RetentionHours = 720
+
+	plannerConfig := planner.DefaultPlannerConfig()
+	conf.Planner.MaxPlacesPerSlot = plannerConfig.MaxPlacesPerSlot
+	conf.Planner.MaxPlacesPerDay = plannerConfig.MaxPlacesPerDay
+
+	solverConfig := plannerConfig.Solver
+	conf.Solver.TravelSpeed = solverConfig.TravelSpeed
+	conf.Solver.TimeLimitBetweenClusters = solverConfig.TimeLimitBetweenClusters
+	conf.Solver.CandidateQueueLength = solverConfig.CandidateQueueLength
+	conf.Solver.TimeClusterMinResults = solverConfig.TimeClusterMinResults
+	conf.Solver.PriceLevels = solverConfig.PriceLevels.Levels[:]
+	conf.Solver.PriceLevelDefault = solverConfig.PriceLevels.Default
+
+	conf.PoiSearcher.MaxSearchRadius = plannerConfig.PoiSearcher.MaxSearchRadius
+	conf.PoiSearcher.MinMapsResultRefreshDuration = plannerConfig.PoiSearcher.MinMapsResultRefreshDuration
+	return conf
+}
+
+// load the configuration file named by CONFIG_FILE if it is set, then apply environment variables
+func LoadConfig() (conf Config, err error) {
+	conf = DefaultConfig()
+	if configFile := os.Getenv(ConfigFileEnvVar); configFile != "" {
+		if err = conf.loadFile(configFile); err != nil {
+			return
+		}
+	}
+	if err = envconfig.Process("", &conf); err != nil {
+		return
+	}
+	err = conf.Validate()
+	return
+}
+
+// unknown keys are rejected, so that misspelled settings are not silently ignored
+func (conf *Config) loadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = yaml.UnmarshalStrict(data, conf); err != nil {
+		return fmt.Errorf("invalid config file %s: %s", path, err.Error())
+	}
+	return nil
+}
+
+func (conf *Config) Validate() error {
+	switch {
+	case conf.Redis.RedisUrl == "":
+		return errors.New("Redis URL is required")
+	case conf.MapsClientApiKey == "":
+		return errors.New("Maps client API key is required")
+	case conf.Server.ServerPort == "":
+		return errors.New("server port is required")
+	case conf.Server.Timeout <= 0:
+		return errors.New("server timeout must be positive")
+	case conf.Server.NumWorkers <= 0:
+		return errors.New("number of workers must be positive")
+	case conf.Server.NumPlanJobWorkers <= 0:
+		return errors.New("number of plan job workers must be positive")
+	case conf.RateLimit.WindowInSeconds < 0:
+		return errors.New("rate limit window cannot be negative")
+	case conf.Mail.Mailer != iowrappers.MailerSMTP && conf.Mail.Mailer != iowrappers.MailerFile && conf.Mail.Mailer != iowrappers.MailerLog:
+		return fmt.Errorf("unknown mailer %s, valid mailers are smtp, file and log", conf.Mail.Mailer)
+	case conf.Mail.Mailer == iowrappers.MailerSMTP && conf.Mail.SMTPHost == "":
+		return errors.New("SMTP host is required for the smtp mailer")
+	case strings.ToLower(os.Getenv("ENVIRONMENT")) == "production" && conf.Mail.Mailer != iowrappers.MailerSMTP:
+		// users cannot verify email addresses or reset passwords without emails
+		return errors.New("smtp mailer is required in production")
+	case conf.StreamArchiver.RetentionHours <= 0:
+		return errors.New("stream retention hours must be positive")
+	case conf.Planner.MaxPlacesPerSlot <= 0 || conf.Planner.MaxPlacesPerDay <= 0:
+		return errors.New("maximum numbers of places must be positive")
+	case conf.Planner.MaxPlacesPerSlot > conf.Planner.MaxPlacesPerDay:
+		return errors.New("maximum number of places per slot cannot exceed maximum number of places per day")
+	case conf.Solver.TravelSpeed <= 0:
+		return errors.New("travel speed must be positive")
+	case conf.Solver.TimeLimitBetweenClusters == 0:
+		return errors.New("time limit between clusters must be positive")
+	case conf.Solver.CandidateQueueLength <= 0:
+		return errors.New("candidate queue length must be positive")
+	case conf.Solver.TimeClusterMinResults == 0:
+		return errors.New("time cluster minimum results must be positive")
+	case len(conf.Solver.PriceLevels) != len(matching.PriceLevels{}.Levels):
+		return fmt.Errorf("%d price levels are required", len(matching.PriceLevels{}.Levels))
+	case conf.Solver.PriceLevels[2] <= 0:
+		// the price of the average price level divides place prices in scores
+		return errors.New("price of price level 2 must be positive")
+	case conf.PoiSearcher.MaxSearchRadius == 0:
+		return errors.New("maximum search radius must be positive")
+	case conf.PoiSearcher.MinMapsResultRefreshDuration < 0:
+		return errors.New("minimum Maps result refresh duration cannot be negative")
+	}
+	for level, price := range conf.Solver.PriceLevels {
+		if price < 0 {
+			return fmt.Errorf("price of price level %d cannot be negative", level)
+		}
+		if level > 0 && price < conf.Solver.PriceLevels[level-1] {
+			return errors.New("prices of price levels must be non-decreasing")
+		}
+	}
+	return nil
+}
+
+func (conf *Config) PlannerConfig() planner.PlannerConfig {
+	priceLevels := matching.PriceLevels{Default: conf.Solver.PriceLevelDefault}
+	copy(priceLevels.Levels[:], conf.Solver.PriceLevels)
+	return planner.PlannerConfig{
+		MaxPlacesPerSlot: conf.Planner.MaxPlacesPerSlot,
+		MaxPlacesPerDay:  conf.Planner.MaxPlacesPerDay,
+		ServerTimeout:    conf.Server.Timeout,
+		Solver: solution.SolverConfig{
+			TravelSpeed:              conf.Solver.TravelSpeed,
+			TimeLimitBetweenClusters: conf.Solver.TimeLimitBetweenClusters,
+			CandidateQueueLength:     conf.Solver.CandidateQueueLength,
+			TimeClusterMinResults:    conf.Solver.TimeClusterMinResults,
+			PriceLevels:              priceLevels,
+		},
+		PoiSearcher: iowrappers.PoiSearcherConfig{
+			MaxSearchRadius:              conf.PoiSearcher.MaxSearchRadius,
+			MinMapsResultRefreshDuration: conf.PoiSearcher.MinMapsResultRefreshDuration,
+		},
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func validTestConfig() Config {
+	conf := DefaultConfig()
+	conf.Redis.RedisUrl = "redis://localhost:6379"
+	conf.MapsClientApiKey = "maps_api_key"
+	return conf
+}
+
+func TestValidateConfig(t *testing.T) {
+	conf := validTestConfig()
+	assert.Nil(t, conf.Validate())
+
+	// scores divide by the price of the average price level
+	conf.Solver.PriceLevels = []float64{0, 0, 0, 0, 0}
+	assert.EqualError(t, conf.Validate(), "price of price level 2 must be positive")
+	conf.Solver.PriceLevels = []float64{0, 0, 1, 1, 1}
+	assert.Nil(t, conf.Validate())
+	conf.Solver.PriceLevels = []float64{0, 10, 5, 50, 100}
+	assert.EqualError(t, conf.Validate(), "prices of price levels must be non-decreasing")
+
+	conf = validTestConfig()
+	conf.Server.NumPlanJobWorkers = 0
+	assert.EqualError(t, conf.Validate(), "number of plan job workers must be positive")
+}
@@ -2,31 +2,18 @@ package main
 
 import (
 	"github.com/braintree/manners"
-	"github.com/kelseyhightower/envconfig"
 	log "github.com/sirupsen/logrus"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
 	"github.com/weihesdlegend/Vacation-planner/planner"
 	"net/url"
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 )
 
-const numWorkers = 5
-
-type Config struct {
-	Server struct {
-		ServerPort string `envconfig:"PORT" default:"10000"`
-	}
-	Redis struct {
-		RedisUrl        string `envconfig:"REDISCLOUD_URL" required:"true"`
-		RedisStreamName string `default:"stream:planning_api_usage"`
-	}
-	MapsClientApiKey string `required:"true" split_words:"true"`
-}
-
 func RunServer() {
-	conf := Config{}
-	err := envconfig.Process("", &conf)
+	conf, err := LoadConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -37,7 +24,50 @@ func RunServer() {
 	}
 
 	myPlanner := planner.MyPlanner{}
-	myPlanner.Init(conf.MapsClientApiKey, redisURL, conf.Redis.RedisStreamName)
+	myPlanner.Init(conf.MapsClientApiKey, redisURL, conf.Redis.RedisStreamName, conf.PlannerConfig())
+	myPlanner.RateLimits.Window = time.Duration(conf.RateLimit.WindowInSeconds) * time.Second
+	myPlanner.RateLimits.UserLimit = conf.RateLimit.UserLimit
+	myPlanner.RateLimits.GuestLimit = conf.RateLimit.GuestLimit
+	myPlanner.RateLimits.TrustedProxies = conf.RateLimit.TrustedProxies
+	for endpoint, limit := range conf.RateLimit.EndpointLimits {
+		myPlanner.RateLimits.EndpointLimits[endpoint] = limit
+	}
+	switch conf.Mail.Mailer {
+	case iowrappers.MailerSMTP:
+		myPlanner.Mailer = &iowrappers.SMTPMailer{
+			Host:     conf.Mail.SMTPHost,
+			Port:     conf.Mail.SMTPPort,
+			Username: conf.Mail.SMTPUsername,
+			Password: conf.Mail.SMTPPassword,
+			From:     conf.Mail.From,
+		}
+	case iowrappers.MailerFile:
+		myPlanner.Mailer = &iowrappers.FileMailer{Dir: conf.Mail.Dir}
+	}
+	myPlanner.PublicURL = conf.Mail.PublicURL
+	myPlanner.ReadinessCheckMapsKey = conf.Server.ReadinessCheckMapsKey
+	if conf.Server.CaptureRequestsFile != "" {
+		myPlanner.RequestRecorder, err = planner.NewRequestRecorder(conf.Server.CaptureRequestsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var archiver *iowrappers.StreamArchiver
+	if conf.Mongo.URI != "" {
+		dbHandler := &iowrappers.DbHandler{}
+		dbHandler.Init(conf.Mongo.DbName, conf.Mongo.URI)
+		myPlanner.DbHandler = dbHandler
+		if dbHandler.Session != nil {
+			archiver = &iowrappers.StreamArchiver{
+				RedisClient: &myPlanner.RedisClient,
+				Archive:     dbHandler,
+				Stream:      myPlanner.RedisStreamName,
+				Consumer:    conf.StreamArchiver.Consumer,
+				Retention:   time.Duration(conf.StreamArchiver.RetentionHours) * time.Hour,
+			}
+		}
+	}
 	svr := myPlanner.SetupRouter(conf.Server.ServerPort)
 
 	c := make(chan os.Signal, 1)
@@ -45,21 +75,32 @@ func RunServer() {
 
 	graceSvr := manners.NewWithServer(svr)
 
-	go listenForShutDownServer(c, graceSvr, &myPlanner)
+	shutDown := make(chan struct{})
+	go func() {
+		listenForShutDownServer(c, graceSvr, &myPlanner, archiver, conf.Server.NumWorkers, conf.Server.NumPlanJobWorkers)
+		close(shutDown)
+	}()
 
 	err = graceSvr.ListenAndServe()
 	if err != nil {
 		log.Fatal(err)
 	}
+	// wait for the workers to finish queued jobs and events
+	<-shutDown
 
 	log.Info("Server gracefully shut down")
 }
 
+// the REST server is started without a command
 func main() {
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		os.Exit(RunCommand(os.Args[1:], os.Stdout, os.Stderr))
+	}
 	RunServer()
 }
 
-func listenForShutDownServer(ch <-chan os.Signal, svr *manners.GracefulServer, myPlanner *planner.MyPlanner) {
+func listenForShutDownServer(ch <-chan os.Signal, svr *manners.GracefulServer, myPlanner *planner.MyPlanner,
+	archiver *iowrappers.StreamArchiver, numWorkers int, numPlanJobWorkers int) {
 	wg := &sync.WaitGroup{}
 	wg.Add(numWorkers)
 	// dispatch workers
@@ -67,14 +108,35 @@ func listenForShutDownServer(ch <-chan os.Signal, svr *manners.GracefulServer, m
 		go myPlanner.ProcessPlanningEvent(worker, wg)
 	}
 
+	jobWg := &sync.WaitGroup{}
+	jobWg.Add(numPlanJobWorkers)
+	for worker := 0; worker < numPlanJobWorkers; worker++ {
+		go myPlanner.ProcessPlanningJob(worker, jobWg)
+	}
+
+	// archive planning events in the background
+	stopArchiver := make(chan struct{})
+	archiverDone := make(chan struct{})
+	go func() {
+		if archiver != nil {
+			archiver.Run(stopArchiver)
+		}
+		close(archiverDone)
+	}()
+
 	// block and wait for shut-down signal
 	<- ch
 
 	// destroy zap logger
 	defer myPlanner.Destroy()
+	// stop accepting requests and wait for requests in progress, which may queue jobs and events
+	svr.BlockingClose()
 	// close worker channels
+	// planning jobs emit planning events, so job workers finish first
+	close(myPlanner.PlanningJobs)
+	jobWg.Wait()
 	close(myPlanner.PlanningEvents)
 	wg.Wait()
-
-	svr.Close()
+	close(stopArchiver)
+	<-archiverDone
 }
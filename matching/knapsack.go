@@ -78,7 +78,7 @@ func Knapsackv2(places []Place, timeLimit uint8, budget uint) (results []Place)
 				newSolution := make([]Place, len(record.Solution))
 				copy(newSolution, record.Solution)
 				newSolution = append(newSolution, places[k])
-				newScore := Score(newSolution)
+				newScore := Score(newSolution, DefaultPriceLevels())
 				newRecord := knapsackNodeRecord{newTravelTime, newCost, newScore, newSolution}
 				if alreadyRecord, ok := rt.NewRecord[newKey]; ok {
 					if alreadyRecord.score < newRecord.score {
@@ -59,7 +59,7 @@ func Knapsack(places []Place, timeLimit uint8, budget uint) (results []Place) {
 		//INITIALIZE 0,0
 		if uint8(staytime) <= timeLimit && int(math.Ceil(places[k].GetPrice())) <= int(budget) {
 			tempPlaces = append(current[0][0].solution, places[k])
-			tempScore = Score(tempPlaces)
+			tempScore = Score(tempPlaces, DefaultPriceLevels())
 			if tempScore > next[staytime][int(math.Ceil(places[k].GetPrice()))].score {
 				next[staytime][int(math.Round(places[k].GetPrice()))].score = tempScore
 				next[staytime][int(math.Round(places[k].GetPrice()))].solution = append(make([]Place, 0, len(tempPlaces)), tempPlaces...)
@@ -77,7 +77,7 @@ func Knapsack(places []Place, timeLimit uint8, budget uint) (results []Place) {
 						tempi = i + int(staytime)
 						tempj = j + int(math.Ceil(places[k].GetPrice()))
 						tempPlaces = append(current[i][j].solution, places[k])
-						tempScore = Score(tempPlaces)
+						tempScore = Score(tempPlaces, DefaultPriceLevels())
 						if tempScore > next[tempi][tempj].score {
 							next[tempi][tempj].score = tempScore
 							next[tempi][tempj].solution = append(make([]Place, 0, len(tempPlaces)), tempPlaces...)
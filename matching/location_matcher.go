@@ -17,6 +17,7 @@ type PlacePair struct {
 type LocationMatcher struct {
 	EateryClusterMgr *graph.ClustersManager
 	VisitClusterMgr  *graph.ClustersManager
+	PriceLevels      PriceLevels // default price levels are used if not set
 }
 
 type LocationMatchingRequest struct {
@@ -34,6 +35,9 @@ func (m *LocationMatcher) Matching(req *LocationMatchingRequest, mapsClient *iow
 		3) calculate cluster centers
 		4) generate Place pairs
 	*/
+	if m.PriceLevels == (PriceLevels{}) {
+		m.PriceLevels = DefaultPriceLevels()
+	}
 	m.createClusterManager(mapsClient, req.NumClusters)
 
 	m.clustering(req.Location, req.Radius, req.NumClusters)
@@ -55,8 +59,8 @@ func (m *LocationMatcher) genPlacePairs(clusterPair *clusterCenterPair) (placePa
 
 	for _, visitPlace := range visitCluster.Places {
 		for _, eateryPlace := range eateryCluster.Places {
-			price := checkPrice(visitPlace.GetPriceLevel()) +
-				checkPrice(eateryPlace.GetPriceLevel())
+			price := m.PriceLevels.Price(visitPlace.GetPriceLevel()) +
+				m.PriceLevels.Price(eateryPlace.GetPriceLevel())
 			placePairs = append(placePairs,
 				PlacePair{visitPlace.GetName(), eateryPlace.GetName(), price})
 		}
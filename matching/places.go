@@ -54,11 +54,16 @@ func (place Place) SetURL(url string) {
 	place.Place.SetURL(url)
 }
 
+// create a place priced with the default price levels
 func CreatePlace(place POI.Place, category POI.PlaceCategory) Place {
+	return CreatePricedPlace(place, category, DefaultPriceLevels())
+}
+
+func CreatePricedPlace(place POI.Place, category POI.PlaceCategory, priceLevels PriceLevels) Place {
 	Place_ := Place{}
 	Place_.Place = &place
 	Place_.Address = place.GetFormattedAddress()
-	Place_.Price = checkPrice(place.GetPriceLevel())
+	Place_.Price = priceLevels.Price(place.GetPriceLevel())
 	Place_.Location = place.GetLocation()
 	Place_.Category = category
 	return Place_
@@ -1,5 +1,6 @@
 package matching
 
+// default prices of Google Maps price levels
 const (
 	PriceLevelDefault = -1.0
 	PriceLevel0       = 0.0
@@ -9,19 +10,28 @@ const (
 	PriceLevel4       = 100.0
 )
 
-func checkPrice(priceLevel int) float64 {
-	switch priceLevel {
-	case 0:
-		return PriceLevel0
-	case 1:
-		return PriceLevel1
-	case 2:
-		return PriceLevel2
-	case 3:
-		return PriceLevel3
-	case 4:
-		return PriceLevel4
-	default:
-		return PriceLevelDefault
+// prices of Google Maps price levels from 0 (free) to 4 (very expensive)
+// places without a valid price level have the default price
+type PriceLevels struct {
+	Levels  [5]float64
+	Default float64
+}
+
+func DefaultPriceLevels() PriceLevels {
+	return PriceLevels{
+		Levels:  [5]float64{PriceLevel0, PriceLevel1, PriceLevel2, PriceLevel3, PriceLevel4},
+		Default: PriceLevelDefault,
 	}
 }
+
+func (priceLevels PriceLevels) Price(priceLevel int) float64 {
+	if priceLevel < 0 || priceLevel >= len(priceLevels.Levels) {
+		return priceLevels.Default
+	}
+	return priceLevels.Levels[priceLevel]
+}
+
+// price of places without a price in scores, which is the price of price level 2 (moderate)
+func (priceLevels PriceLevels) Average() float64 {
+	return priceLevels.Levels[2]
+}
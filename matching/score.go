@@ -8,15 +8,13 @@ import (
 	"math"
 )
 
-const (
-	AvgRating  = 3.0
-	AvgPricing = PriceLevel2
-)
+const AvgRating = 3.0
 
-func Score(places []Place) float64 {
+// prices of places are the prices of the price levels, free places are scored with the average price
+func Score(places []Place, priceLevels PriceLevels) float64 {
 	if len(places) == 1 {
 		if places[0].GetPrice() == 0 {
-			return AvgRating / AvgPricing // set to average single Place rating-price ratio
+			return AvgRating / priceLevels.Average() // set to average single Place rating-price ratio
 		}
 		return float64(places[0].GetRating()) / places[0].GetPrice()
 	}
@@ -24,7 +22,7 @@ func Score(places []Place) float64 {
 	maxDist := math.Max(0.001, calMaxDistance(distances)) // protect against maximum distance being zero
 	avgDistance := stat.Mean(distances, nil) / maxDist    // normalized average distance
 
-	avgRatingPriceRatio := calAvgRatingPriceRatio(places) // normalized average rating to price ratio
+	avgRatingPriceRatio := calAvgRatingPriceRatio(places, priceLevels.Average()) // normalized average rating to price ratio
 
 	return avgRatingPriceRatio - avgDistance
 }
@@ -46,12 +44,12 @@ func calMaxDistance(distances []float64) float64 {
 }
 
 // calculate normalized average rating to price ratio
-func calAvgRatingPriceRatio(places []Place) float64 {
+func calAvgRatingPriceRatio(places []Place, avgPrice float64) float64 {
 	numPlaces := len(places)
 	ratingPriceRatios := make([]float64, numPlaces)
 	for k, place := range places {
 		if place.GetPrice() == 0 {
-			ratingPriceRatios[k] = AvgRating / AvgPricing
+			ratingPriceRatios[k] = AvgRating / avgPrice
 		} else {
 			ratio := float64(place.GetRating()) / place.GetPrice()
 			ratingPriceRatios[k] = ratio
@@ -9,6 +9,7 @@ import (
 	"github.com/weihesdlegend/Vacation-planner/iowrappers"
 	"reflect"
 	"sort"
+	"time"
 )
 
 type Matcher interface {
@@ -19,17 +20,26 @@ type TimeMatcher struct {
 	PoiSearcher *iowrappers.PoiSearcher
 	CateringMgr *graph.TimeClustersManager
 	TouringMgr  *graph.TimeClustersManager
+	// minimum number of places of each category found by nearby search
+	MinSearchResults uint
+	PriceLevels      PriceLevels
 }
 
 type TimeSlot struct {
 	Slot POI.TimeInterval
 }
 
+// called after nearby search of a place category is done
+type PlaceSearchCallback func(placeCat POI.PlaceCategory, numPlaces int)
+
 type TimeMatchingRequest struct {
-	Location  string      // city,country
-	Radius    uint        // search Radius
-	TimeSlots []TimeSlot  // division of day
-	Weekday   POI.Weekday // Weekday
+	Location      string              // city,country
+	Radius        uint                // search Radius
+	TimeSlots     []TimeSlot          // division of day
+	Weekday       POI.Weekday         // Weekday
+	OnPlaceSearch PlaceSearchCallback // optional, reports search progress
+	// time spent in nearby search, set by Matching
+	SearchDuration time.Duration
 }
 
 type PlaceCluster struct {
@@ -37,11 +47,13 @@ type PlaceCluster struct {
 	Slot   TimeSlot `json:"time slot"`
 }
 
-func (matcher *TimeMatcher) Init(poiSearcher *iowrappers.PoiSearcher) {
+func (matcher *TimeMatcher) Init(poiSearcher *iowrappers.PoiSearcher, minSearchResults uint, priceLevels PriceLevels) {
 	if reflect.ValueOf(poiSearcher).IsNil() {
 		log.Fatal("PoiSearcher does not exist")
 	}
 	matcher.PoiSearcher = poiSearcher
+	matcher.MinSearchResults = minSearchResults
+	matcher.PriceLevels = priceLevels
 	matcher.CateringMgr = &graph.TimeClustersManager{PlaceCat: POI.PlaceCategoryEatery}
 	matcher.TouringMgr = &graph.TimeClustersManager{PlaceCat: POI.PlaceCategoryVisit}
 }
@@ -91,7 +103,7 @@ func (matcher *TimeMatcher) timeClustering(placeCat POI.PlaceCategory, clusterMa
 		}
 		cluster := mgr.TimeClusters.Clusters[clusterKey]
 		for _, place := range cluster.Places {
-			(*clusterMap[clusterKey]).Places = append((*clusterMap[clusterKey]).Places, CreatePlace(place, placeCat))
+			(*clusterMap[clusterKey]).Places = append((*clusterMap[clusterKey]).Places, CreatePricedPlace(place, placeCat, matcher.PriceLevels))
 		}
 	}
 
@@ -115,8 +127,15 @@ func (matcher *TimeMatcher) placeSearch(req *TimeMatchingRequest, placeCat POI.P
 	}
 
 	// this is how to use TimeClustersManager
-	mgr.Init(matcher.PoiSearcher, placeCat, intervals, req.Weekday)
+	mgr.Init(matcher.PoiSearcher, placeCat, intervals, req.Weekday, matcher.MinSearchResults)
+	searchStartTime := time.Now()
 	mgr.PlaceSearch(req.Location, req.Radius)
+	searchDuration := time.Since(searchStartTime)
+	req.SearchDuration += searchDuration
+	iowrappers.ObserveSolverStage(iowrappers.SolverStageNearbySearch, searchDuration)
+	if req.OnPlaceSearch != nil {
+		req.OnPlaceSearch(placeCat, mgr.NumPlaces())
+	}
 	mgr.Clustering(req.Weekday)
 
 	return
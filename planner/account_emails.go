@@ -0,0 +1,177 @@
+package planner
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const MinPasswordLength = 8
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// the request is sent in JSON, or as a form from the password reset page
+type ResetPasswordRequest struct {
+	Token    string `json:"token" form:"token"`
+	Password string `json:"password" form:"password"`
+}
+
+// the page linked in password reset emails, the form posts the token and the new password to the password reset API
+var passwordResetPage = template.Must(template.New("password_reset").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>Reset your password</title></head>
+<body>
+<h1>Reset your password</h1>
+<form method="post" action="/v1/password/reset">
+  <input type="hidden" name="token" value="{{.}}">
+  <label>New password <input type="password" name="password" minlength="8" required></label>
+  <button type="submit">Reset password</button>
+</form>
+</body>
+</html>
+`))
+
+// links in emails point to the configured public URL instead of the Host header of requests
+func (planner *MyPlanner) publicLink(path string, token string) string {
+	return strings.TrimRight(planner.PublicURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func (planner *MyPlanner) sendVerificationEmail(username string, email string) error {
+	token, err := planner.RedisClient.CreateUserToken(iowrappers.EmailVerificationToken, username, iowrappers.EmailVerificationTimeout)
+	if err != nil {
+		return err
+	}
+	return planner.Mailer.Send(iowrappers.Mail{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: "Hi " + username + ",\n\n" +
+			"Please verify your email address by opening the link below within 24 hours.\n\n" +
+			planner.publicLink("/v1/email/verify", token) + "\n",
+	})
+}
+
+func (planner *MyPlanner) sendPasswordResetEmail(username string, email string) error {
+	token, err := planner.RedisClient.CreateUserToken(iowrappers.PasswordResetToken, username, iowrappers.PasswordResetTimeout)
+	if err != nil {
+		return err
+	}
+	return planner.Mailer.Send(iowrappers.Mail{
+		To:      email,
+		Subject: "Reset your password",
+		Body: "Hi " + username + ",\n\n" +
+			"Use the token below to reset your password within one hour. If you did not ask for a password reset, ignore this email.\n\n" +
+			"Token: " + token + "\n\n" +
+			planner.publicLink("/v1/password/reset", token) + "\n",
+	})
+}
+
+// HTTP GET API end-point for verifying the email address of an user with the link in the verification email
+func (planner *MyPlanner) verifyEmailApi(c *gin.Context) {
+	username, err := planner.RedisClient.ConsumeUserToken(iowrappers.EmailVerificationToken, c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err = planner.RedisClient.SetEmailVerified(username); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "email verified"})
+}
+
+// HTTP POST API end-point for sending the verification email again
+func (planner *MyPlanner) resendVerificationEmailApi(c *gin.Context) {
+	username, err := planner.UserAuthentication(c.Request)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUser, err := planner.RedisClient.FindUser(username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if currentUser.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user has no email address"})
+		return
+	}
+	if currentUser.EmailVerified {
+		c.JSON(http.StatusOK, gin.H{"status": "email already verified"})
+		return
+	}
+
+	if utils.CheckErrImmediate(planner.sendVerificationEmail(username, currentUser.Email), utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send the verification email"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "verification email sent"})
+}
+
+// HTTP POST API end-point for requesting a password reset email
+// the response is the same whether or not the email address belongs to an user
+func (planner *MyPlanner) forgotPasswordApi(c *gin.Context) {
+	req := ForgotPasswordRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	if u, err := planner.RedisClient.FindUserByEmail(req.Email); err == nil {
+		// send in the background so that response times do not tell whether the user exists
+		go func() {
+			utils.CheckErrImmediate(planner.sendPasswordResetEmail(u.Username, u.Email), utils.LogError)
+		}()
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "if the email address belongs to an user, a password reset email is sent"})
+}
+
+// HTTP GET API end-point for the page linked in the password reset email
+// the token is only checked when the form is submitted, so that opening the link does not use the token
+func (planner *MyPlanner) passwordResetPageApi(c *gin.Context) {
+	c.Header("Content-Type", gin.MIMEHTML+"; charset=utf-8")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Status(http.StatusOK)
+	utils.CheckErrImmediate(passwordResetPage.Execute(c.Writer, c.Query("token")), utils.LogError)
+}
+
+// HTTP POST API end-point for resetting the password with the token in the password reset email
+// all tokens and API keys of the user are invalidated, so that sessions of anyone who knew the old password end
+func (planner *MyPlanner) resetPasswordApi(c *gin.Context) {
+	req := ResetPasswordRequest{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Password) < MinPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password must have at least 8 characters"})
+		return
+	}
+
+	username, err := planner.RedisClient.ConsumeUserToken(iowrappers.PasswordResetToken, req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err = planner.RedisClient.UpdatePassword(username, req.Password); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err = planner.RedisClient.InvalidateTokens(username); utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to end sessions of the user"})
+		return
+	}
+	if err = planner.RedisClient.RevokeAPIKeys(username); utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke API keys of the user"})
+		return
+	}
+	// receiving the reset email proves the ownership of the email address
+	utils.CheckErrImmediate(planner.RedisClient.SetEmailVerified(username), utils.LogError)
+	c.JSON(http.StatusOK, gin.H{"status": "password updated"})
+}
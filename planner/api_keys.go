@@ -0,0 +1,98 @@
+package planner
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const MaxAPIKeyNameLength = 64
+
+type APIKeyCreateRequest struct {
+	Name string `json:"name"`
+}
+
+// the key is only returned when it is created
+type APIKeyCreatedResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Key       string    `json:"key"`
+}
+
+// API keys are managed by logged-in users, an API key cannot be used to create or revoke other keys
+// tokens of users removed or disabled after login cannot manage API keys
+func (planner *MyPlanner) authenticateLogin(c *gin.Context) (username string, ok bool) {
+	username, err := planner.tokenAuthentication(c.Request)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login is required for managing API keys"})
+		return "", false
+	}
+	if err = planner.RedisClient.CheckUserActive(username); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return username, true
+}
+
+// HTTP POST API end-point for creating an API key of the current user
+func (planner *MyPlanner) createAPIKeyApi(c *gin.Context) {
+	username, ok := planner.authenticateLogin(c)
+	if !ok {
+		return
+	}
+
+	req := APIKeyCreateRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > MaxAPIKeyNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "API key name must have 1 to 64 characters"})
+		return
+	}
+
+	key, record, err := planner.RedisClient.CreateAPIKey(username, req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, APIKeyCreatedResponse{
+		ID:        record.ID,
+		Name:      record.Name,
+		CreatedAt: record.CreatedAt,
+		Key:       key,
+	})
+}
+
+// HTTP GET API end-point for API keys of the current user
+func (planner *MyPlanner) listAPIKeysApi(c *gin.Context) {
+	username, ok := planner.authenticateLogin(c)
+	if !ok {
+		return
+	}
+
+	records, err := planner.RedisClient.ListAPIKeys(username)
+	if utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": records})
+}
+
+// HTTP DELETE API end-point for revoking an API key of the current user
+func (planner *MyPlanner) revokeAPIKeyApi(c *gin.Context) {
+	username, ok := planner.authenticateLogin(c)
+	if !ok {
+		return
+	}
+
+	if err := planner.RedisClient.RevokeAPIKey(username, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "API key revoked"})
+}
@@ -0,0 +1,111 @@
+package planner
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"net/http"
+)
+
+type DayTemplatesResponse struct {
+	Templates []solution.DayTemplate `json:"templates"`
+}
+
+// find a template by name, built-in templates take precedence over templates of the user
+func (planner *MyPlanner) findDayTemplate(username string, name string) (template solution.DayTemplate, err error) {
+	name = solution.NormalizeTemplateName(name)
+	if systemTemplate, exists := solution.SystemDayTemplate(name); exists {
+		return systemTemplate, nil
+	}
+	err = planner.RedisClient.GetDayTemplate(username, name, &template)
+	return
+}
+
+// HTTP GET API end-point for built-in templates and templates of the current user
+func (planner *MyPlanner) listDayTemplatesApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	userTemplates, err := planner.RedisClient.ListDayTemplates(username)
+	if utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list templates"})
+		return
+	}
+
+	resp := DayTemplatesResponse{Templates: solution.SystemDayTemplates()}
+	for _, userTemplate := range userTemplates {
+		template := solution.DayTemplate{}
+		if utils.CheckErrImmediate(json.Unmarshal(userTemplate, &template), utils.LogError) {
+			continue
+		}
+		resp.Templates = append(resp.Templates, template)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// HTTP GET API end-point for a template
+func (planner *MyPlanner) getDayTemplateApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	template, err := planner.findDayTemplate(username, c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// HTTP POST API end-point for creating a template or replacing a template with the same name
+func (planner *MyPlanner) saveDayTemplateApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	template := solution.DayTemplate{}
+	err := c.ShouldBindJSON(&template)
+	utils.CheckErrImmediate(err, utils.LogInfo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template.Name = solution.NormalizeTemplateName(template.Name)
+	template.System = false
+	if err = template.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = planner.RedisClient.SaveDayTemplate(username, template.Name, template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+// HTTP DELETE API end-point for a template of the current user
+func (planner *MyPlanner) deleteDayTemplateApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	name := solution.NormalizeTemplateName(c.Param("name"))
+	if _, exists := solution.SystemDayTemplate(name); exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "built-in templates cannot be deleted"})
+		return
+	}
+
+	if err := planner.RedisClient.DeleteDayTemplate(username, name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "template deleted"})
+}
@@ -0,0 +1,126 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DependencyStatusOK          = "ok"
+	DependencyStatusUnavailable = "unavailable"
+	// the instance is not ready if the planning events queue is almost full
+	planningEventsBacklogThreshold = 0.9
+	// the Maps key is validated with a billed API call, so the result is reused for a while
+	mapsKeyCheckInterval = time.Minute * 10
+)
+
+// a sample location for validating the Maps key with a time zone request
+var mapsKeyCheckLocation = [2]float64{51.5074, -0.1278} // latitude, longitude
+
+type DependencyCheck struct {
+	Status    string                 `json:"status"`
+	LatencyMs float64                `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status string                     `json:"status"`
+	Checks map[string]DependencyCheck `json:"checks"`
+}
+
+// the latest result of Maps key validation
+type cachedDependencyCheck struct {
+	mutex     sync.Mutex
+	check     DependencyCheck
+	checkedAt time.Time
+}
+
+func timedCheck(check func() error) DependencyCheck {
+	startTime := time.Now()
+	err := check()
+	result := DependencyCheck{
+		Status:    DependencyStatusOK,
+		LatencyMs: float64(time.Since(startTime).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = DependencyStatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// HTTP GET API end-point for liveness
+// the process is alive as long as it can serve requests, dependencies are checked by the readiness end-point
+func (planner *MyPlanner) livenessApi(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": DependencyStatusOK})
+}
+
+// HTTP GET API end-point for readiness
+// responds with service unavailable status if any dependency check fails
+func (planner *MyPlanner) readinessApi(c *gin.Context) {
+	checks := map[string]DependencyCheck{
+		"redis":                 timedCheck(planner.RedisClient.Ping),
+		"templates":             timedCheck(planner.checkTemplates),
+		"planning_events_queue": planner.checkPlanningEventsQueue(),
+	}
+	if planner.DbHandler != nil {
+		checks["mongo"] = timedCheck(planner.DbHandler.Ping)
+	}
+	if planner.ReadinessCheckMapsKey {
+		checks["maps"] = planner.checkMapsKey()
+	}
+
+	resp := ReadinessResponse{Status: DependencyStatusOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status != DependencyStatusOK {
+			resp.Status = DependencyStatusUnavailable
+			c.JSON(http.StatusServiceUnavailable, resp)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (planner *MyPlanner) checkTemplates() error {
+	if planner.HomeHTMLTemplate == nil || planner.ResultHTMLTemplate == nil {
+		return errors.New("HTML templates are not loaded")
+	}
+	return nil
+}
+
+func (planner *MyPlanner) checkPlanningEventsQueue() DependencyCheck {
+	depth, capacity := len(planner.PlanningEvents), cap(planner.PlanningEvents)
+	check := timedCheck(func() error {
+		if capacity > 0 && float64(depth) >= planningEventsBacklogThreshold*float64(capacity) {
+			return fmt.Errorf("planning events queue has a backlog of %d events", depth)
+		}
+		return nil
+	})
+	check.Details = map[string]interface{}{"depth": depth, "capacity": capacity}
+	return check
+}
+
+func (planner *MyPlanner) checkMapsKey() DependencyCheck {
+	planner.mapsKeyCheck.mutex.Lock()
+	defer planner.mapsKeyCheck.mutex.Unlock()
+
+	if !planner.mapsKeyCheck.checkedAt.IsZero() && time.Since(planner.mapsKeyCheck.checkedAt) < mapsKeyCheckInterval {
+		return planner.mapsKeyCheck.check
+	}
+	check := timedCheck(func() error {
+		if planner.mapsClient == nil {
+			return errors.New("maps client does not exist")
+		}
+		_, err := planner.mapsClient.GetTimeZone(mapsKeyCheckLocation[0], mapsKeyCheckLocation[1])
+		return err
+	})
+	check.Details = map[string]interface{}{"checked_at": time.Now().UTC().Format(time.RFC3339)}
+	planner.mapsKeyCheck.check = check
+	planner.mapsKeyCheck.checkedAt = time.Now()
+	return check
+}
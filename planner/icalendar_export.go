@@ -0,0 +1,136 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MIMECalendar            = "text/calendar"
+	iCalendarProductId      = "-//Unwind//Vacation Planner//EN"
+	iCalendarDateTimeLayout = "20060102T150405"
+	iCalendarDateLayout     = "20060102"
+	iCalendarLineLimit      = 75 // maximum number of octets in a line excluding line break
+)
+
+// find the first date on or after the current date that falls on the weekday
+func NextDateOnWeekday(now time.Time, weekday POI.Weekday) time.Time {
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	numDays := (int(weekday) - int(POI.GetWeekday(date)) + 7) % 7
+	return date.AddDate(0, 0, numDays)
+}
+
+// ToICalendar converts one of the plans in a planning response to an RFC 5545 VCALENDAR
+// each place is an event on the given date in the time zone of the destination
+// event times are in UTC if the time zone is known, otherwise floating local times are used
+func ToICalendar(resp PlanningResponse, planIdx int, date time.Time) (string, error) {
+	if planIdx < 0 || planIdx >= len(resp.Places) {
+		return "", errors.New("plan option does not exist")
+	}
+
+	location, timeZoneErr := time.LoadLocation(resp.TimeZone)
+	isFloatingTime := resp.TimeZone == "" || timeZoneErr != nil
+	formatTime := func(timeOfDay POI.TimeOfDay) string {
+		if isFloatingTime {
+			return time.Date(date.Year(), date.Month(), date.Day(), timeOfDay.Hour(), timeOfDay.Minute(), 0, 0, time.UTC).Format(iCalendarDateTimeLayout)
+		}
+		t := time.Date(date.Year(), date.Month(), date.Day(), timeOfDay.Hour(), timeOfDay.Minute(), 0, 0, location)
+		return t.UTC().Format(iCalendarDateTimeLayout) + "Z"
+	}
+
+	var sb strings.Builder
+	writeLine := func(line string) {
+		sb.WriteString(foldICalendarLine(line))
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:" + iCalendarProductId)
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeICalendarText("Vacation Plan for "+resp.TravelDestination))
+
+	timestamp := time.Now().UTC().Format(iCalendarDateTimeLayout) + "Z"
+	eventIdx := 0
+	for _, timeSectionPlaces := range resp.Places[planIdx] {
+		for _, place := range timeSectionPlaces.Places {
+			uid := strings.Join([]string{date.Format(iCalendarDateLayout), strconv.Itoa(planIdx), strconv.Itoa(eventIdx), resp.PlanID}, "-")
+			writeLine("BEGIN:VEVENT")
+			writeLine("UID:" + uid + "@unwind.dev")
+			writeLine("DTSTAMP:" + timestamp)
+			writeLine("DTSTART:" + formatTime(place.StartTime))
+			writeLine("DTEND:" + formatTime(place.EndTime))
+			writeLine("SUMMARY:" + escapeICalendarText(place.PlaceName))
+			if place.URL != "" && !strings.ContainsAny(place.URL, "\"") {
+				writeLine(fmt.Sprintf("LOCATION;ALTREP=\"%s\":%s", place.URL, escapeICalendarText(place.Address)))
+				writeLine("URL:" + place.URL)
+			} else {
+				writeLine("LOCATION:" + escapeICalendarText(place.Address))
+			}
+			writeLine("END:VEVENT")
+			eventIdx++
+		}
+	}
+	writeLine("END:VCALENDAR")
+	return sb.String(), nil
+}
+
+// escape TEXT property values per RFC 5545 section 3.3.11
+func escapeICalendarText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// fold content lines longer than 75 octets per RFC 5545 section 3.1
+// multi-octet UTF-8 characters are never split
+func foldICalendarLine(line string) string {
+	var sb strings.Builder
+	lineLength := 0
+	for _, r := range line {
+		runeLength := utf8.RuneLen(r)
+		if lineLength+runeLength > iCalendarLineLimit {
+			sb.WriteString("\r\n ")
+			lineLength = 1
+		}
+		sb.WriteRune(r)
+		lineLength += runeLength
+	}
+	sb.WriteString("\r\n")
+	return sb.String()
+}
+
+// respond with one of the plans as an iCalendar file
+// the plan option is selected with the "option" query parameter, defaults to the top plan
+func renderICalendar(c *gin.Context, resp PlanningResponse) {
+	planIdx, err := strconv.Atoi(c.DefaultQuery("option", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "plan option must be an integer"})
+		return
+	}
+
+	location, timeZoneErr := time.LoadLocation(resp.TimeZone)
+	if timeZoneErr != nil {
+		location = time.UTC
+	}
+	// plans requested with a weekday instead of a date, and plans made before dates were supported, only have a weekday
+	date, dateErr := POI.ParseDate(resp.Date, location)
+	if resp.Date == "" || dateErr != nil {
+		date = NextDateOnWeekday(time.Now().In(location), resp.Weekday)
+	}
+
+	calendar, err := ToICalendar(resp, planIdx, date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"vacation_plan.ics\"")
+	c.Data(http.StatusOK, MIMECalendar+"; charset=utf-8", []byte(calendar))
+}
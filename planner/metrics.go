@@ -0,0 +1,23 @@
+package planner
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"strconv"
+	"time"
+)
+
+// record the latency of each request by the route template, so that path parameters do not create new series
+func requestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		c.Next()
+
+		endpoint := c.FullPath()
+		if endpoint == "" {
+			endpoint = "unmatched"
+		}
+		iowrappers.HTTPRequestDuration.WithLabelValues(c.Request.Method, endpoint, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(startTime).Seconds())
+	}
+}
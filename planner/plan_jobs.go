@@ -0,0 +1,120 @@
+package planner
+
+import (
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"net/http"
+	"sync"
+)
+
+// a planning request queued for the planning job workers
+type PlanningJob struct {
+	ID        string
+	Requester Requester
+	Request   solution.PlanningRequest
+	Trip      *TripPlanningRequest // multi-day trips are planned instead of Request if set
+}
+
+type PlanJobCreatedResponse struct {
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
+
+// solve queued planning requests and record job state in Redis
+// any server instance sharing the Redis database can answer job status queries
+func (planner MyPlanner) ProcessPlanningJob(worker int, wg *sync.WaitGroup) {
+	for job := range planner.PlanningJobs {
+		log.Debugf("worker %d processing planning job %s", worker, job.ID)
+		utils.CheckErrImmediate(planner.RedisClient.UpdatePlanJob(job.ID, iowrappers.PlanJobRunning, nil, ""), utils.LogError)
+
+		var result interface{}
+		var errMsg string
+		if job.Trip != nil {
+			tripResp := planner.TripPlanning(job.Trip, job.Requester)
+			result, errMsg = tripResp, tripResp.Err
+		} else {
+			planningResp := planner.Planning(&job.Request, job.Requester)
+			result, errMsg = planningResp, planningResp.Err
+		}
+		status := iowrappers.PlanJobDone
+		if errMsg != "" {
+			status = iowrappers.PlanJobFailed
+		}
+		utils.CheckErrImmediate(planner.RedisClient.UpdatePlanJob(job.ID, status, result, errMsg), utils.LogError)
+	}
+	wg.Done()
+}
+
+// HTTP POST API end-point for asynchronous planning
+// validates the request and queues it, responds with the job ID immediately
+func (planner *MyPlanner) postPlanJobApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	req := PlanningPostRequest{}
+	err := c.ShouldBindJSON(&req)
+	utils.CheckErrImmediate(err, utils.LogInfo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status_code": http.StatusBadRequest})
+		return
+	}
+
+	planningReq, err := planner.processPlanningPostRequest(&req)
+	utils.CheckErrImmediate(err, utils.LogInfo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status_code": http.StatusBadRequest})
+		return
+	}
+
+	planner.queuePlanningJob(c, username, PlanningJob{Requester: planner.requester(c, username), Request: planningReq})
+}
+
+// create the job state in Redis and queue the job, responds with the job ID
+func (planner *MyPlanner) queuePlanningJob(c *gin.Context, username string, job PlanningJob) {
+	jobId, err := planner.RedisClient.CreatePlanJob(username)
+	if utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create planning job"})
+		return
+	}
+	job.ID = jobId
+
+	select {
+	case planner.PlanningJobs <- job:
+	default:
+		// never block the request when the job queue is full
+		utils.CheckErrImmediate(planner.RedisClient.UpdatePlanJob(jobId, iowrappers.PlanJobFailed, nil, "server is busy"), utils.LogError)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server is busy, please try again later"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, PlanJobCreatedResponse{
+		JobID:     jobId,
+		Status:    iowrappers.PlanJobQueued,
+		StatusURL: "/v1/plan-jobs/" + jobId,
+	})
+}
+
+// HTTP GET API end-point for status and result of a planning job
+func (planner *MyPlanner) getPlanJobApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	job, err := planner.RedisClient.GetPlanJob(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "planning job does not exist"})
+		return
+	}
+	if job.Owner != username {
+		c.JSON(http.StatusForbidden, gin.H{"error": "operation forbidden, not the owner of the planning job"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
@@ -2,7 +2,9 @@ package planner
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/weihesdlegend/Vacation-planner/POI"
 	"github.com/weihesdlegend/Vacation-planner/iowrappers"
 	"github.com/weihesdlegend/Vacation-planner/solution"
@@ -25,8 +27,16 @@ const (
 	jobQueueBufferSize = 1000
 )
 
+const (
+	ResponseFormatHTML    = "html"
+	ResponseFormatJSON    = "json"
+	ResponseFormatICS     = "ics"
+	ResponseFormatGeoJSON = "geojson"
+	ResponseFormatGPX     = "gpx"
+)
+
 type Planner interface {
-	Planning(req *solution.PlanningRequest, user string) (resp PlanningResponse)
+	Planning(req *solution.PlanningRequest, requester Requester) (resp PlanningResponse)
 }
 
 type MyPlanner struct {
@@ -36,15 +46,63 @@ type MyPlanner struct {
 	HomeHTMLTemplate   *template.Template
 	ResultHTMLTemplate *template.Template
 	PlanningEvents     chan iowrappers.PlanningEvent
+	PlanningJobs       chan PlanningJob
+	RateLimits         RateLimitConfig
+	Config             PlannerConfig
+	Mailer             iowrappers.Mailer
+	PublicURL          string // base URL of links in emails
 	Environment        string
+	DbHandler          *iowrappers.DbHandler // optional, MongoDB status is reported by the readiness end-point if set
+	// validate the Maps key in readiness checks
+	ReadinessCheckMapsKey bool
+	mapsClient            *iowrappers.MapsClient
+	mapsKeyCheck          *cachedDependencyCheck
+	// optional, valid planning requests are captured for replay if set
+	RequestRecorder *RequestRecorder
+}
+
+// tunables of the planner, the solver and the POI searcher
+type PlannerConfig struct {
+	MaxPlacesPerSlot int
+	MaxPlacesPerDay  int
+	ServerTimeout    time.Duration // read and write timeout of the server
+	Solver           solution.SolverConfig
+	PoiSearcher      iowrappers.PoiSearcherConfig
+}
+
+func DefaultPlannerConfig() PlannerConfig {
+	return PlannerConfig{
+		MaxPlacesPerSlot: MaxPlacesPerSlot,
+		MaxPlacesPerDay:  MaxPlacesPerDay,
+		ServerTimeout:    ServerTimeout,
+		Solver:           solution.DefaultSolverConfig(),
+		PoiSearcher:      iowrappers.DefaultPoiSearcherConfig(),
+	}
+}
+
+// planners created without Init use the default limits
+func (config PlannerConfig) orDefaults() PlannerConfig {
+	defaultConfig := DefaultPlannerConfig()
+	if config.MaxPlacesPerSlot <= 0 {
+		config.MaxPlacesPerSlot = defaultConfig.MaxPlacesPerSlot
+	}
+	if config.MaxPlacesPerDay <= 0 {
+		config.MaxPlacesPerDay = defaultConfig.MaxPlacesPerDay
+	}
+	if config.ServerTimeout <= 0 {
+		config.ServerTimeout = defaultConfig.ServerTimeout
+	}
+	return config
 }
 
 type TimeSectionPlace struct {
-	PlaceName string   `json:"place_name"`
-	StartTime POI.Hour `json:"start_time"`
-	EndTime   POI.Hour `json:"end_time"`
-	Address   string   `json:"address"`
-	URL       string   `json:"url"`
+	PlaceName string            `json:"place_name"`
+	Category  POI.PlaceCategory `json:"category"`
+	StartTime POI.TimeOfDay     `json:"start_time"`
+	EndTime   POI.TimeOfDay     `json:"end_time"`
+	Address   string            `json:"address"`
+	URL       string            `json:"url"`
+	Location  [2]float64        `json:"location"` // longitude, latitude
 }
 
 type TimeSectionPlaces struct {
@@ -54,6 +112,11 @@ type TimeSectionPlaces struct {
 type PlanningResponse struct {
 	TravelDestination string                `json:"travel_destination"`
 	Places            [][]TimeSectionPlaces `json:"time_section_places"`
+	TravelLegs        []solution.TravelLeg  `json:"travel_legs"`
+	Weekday           POI.Weekday           `json:"weekday"`
+	Date              string                `json:"date,omitempty"` // YYYY-MM-DD at the destination
+	TimeZone          string                `json:"time_zone"`      // IANA time zone ID of the destination
+	PlanID            string                `json:"plan_id,omitempty"`
 	Err               string                `json:"error"`
 	StatusCode        uint                  `json:"status_code"`
 }
@@ -67,18 +130,82 @@ func validateSearchRadius(searchRadius string) bool {
 	return true
 }
 
+// determine response format from the "format" query parameter or the Accept header
+// HTML is the default response format for browsers
+func responseFormat(c *gin.Context) string {
+	switch strings.ToLower(c.Query("format")) {
+	case ResponseFormatJSON:
+		return ResponseFormatJSON
+	case ResponseFormatHTML:
+		return ResponseFormatHTML
+	case ResponseFormatICS:
+		return ResponseFormatICS
+	case ResponseFormatGeoJSON:
+		return ResponseFormatGeoJSON
+	case ResponseFormatGPX:
+		return ResponseFormatGPX
+	}
+	switch c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON, MIMECalendar, MIMEGeoJSON, MIMEGPX) {
+	case gin.MIMEJSON:
+		return ResponseFormatJSON
+	case MIMECalendar:
+		return ResponseFormatICS
+	case MIMEGeoJSON:
+		return ResponseFormatGeoJSON
+	case MIMEGPX:
+		return ResponseFormatGPX
+	}
+	return ResponseFormatHTML
+}
+
+// render planning results in the requested format
+// except for HTML format, errors are returned in JSON with the status code from the solver
+func (planner *MyPlanner) renderPlanningResponse(c *gin.Context, format string, planningResp PlanningResponse) {
+	if format != ResponseFormatHTML && planningResp.Err != "" {
+		c.JSON(int(planningResp.StatusCode), planningResp)
+		return
+	}
+
+	switch format {
+	case ResponseFormatJSON:
+		c.JSON(http.StatusOK, planningResp)
+	case ResponseFormatICS:
+		renderICalendar(c, planningResp)
+	case ResponseFormatGeoJSON, ResponseFormatGPX:
+		renderRoute(c, format, planningResp)
+	default:
+		utils.CheckErrImmediate(planner.ResultHTMLTemplate.Execute(c.Writer, planningResp), utils.LogError)
+	}
+}
+
 type PlanningPostRequest struct {
-	Country   string      `json:"country"`
-	City      string      `json:"city"`
-	Weekday   POI.Weekday `json:"weekday"`
-	StartTime POI.Hour    `json:"start_time"`
-	EndTime   POI.Hour    `json:"end_time"`
-	NumVisit  uint        `json:"num_visit"`
-	NumEatery uint        `json:"num_eatery"`
+	Country   string         `json:"country"`
+	City      string         `json:"city"`
+	Date      string         `json:"date"`    // YYYY-MM-DD, the weekday is derived from the date
+	Weekday   POI.Weekday    `json:"weekday"` // deprecated, used only if date is not provided
+	StartTime POI.TimeOfDay  `json:"start_time"`
+	EndTime   POI.TimeOfDay  `json:"end_time"`
+	NumVisit  uint           `json:"num_visit"`
+	NumEatery uint           `json:"num_eatery"`
+	Stops     []PlanningStop `json:"stops"` // optional, an ordered list of cities visited in the day
+}
+
+// a city visited during part of the day in a multi-city itinerary
+type PlanningStop struct {
+	Country   string        `json:"country"`
+	City      string        `json:"city"`
+	StartTime POI.TimeOfDay `json:"start_time"`
+	EndTime   POI.TimeOfDay `json:"end_time"`
+	NumVisit  uint          `json:"num_visit"`
+	NumEatery uint          `json:"num_eatery"`
 }
 
-func (planner *MyPlanner) Init(mapsClientApiKey string, redisURL *url.URL, redisStreamName string) {
+func (planner *MyPlanner) Init(mapsClientApiKey string, redisURL *url.URL, redisStreamName string, config PlannerConfig) {
+	planner.Config = config
 	planner.PlanningEvents = make(chan iowrappers.PlanningEvent, jobQueueBufferSize)
+	planner.PlanningJobs = make(chan PlanningJob, jobQueueBufferSize)
+	planner.RateLimits = DefaultRateLimitConfig()
+	planner.Mailer = &iowrappers.LogMailer{}
 	planner.RedisClient = iowrappers.CreateRedisClient(redisURL)
 	planner.RedisStreamName = redisStreamName
 	if redisStreamName == "" {
@@ -86,9 +213,10 @@ func (planner *MyPlanner) Init(mapsClientApiKey string, redisURL *url.URL, redis
 	}
 
 	PoiSearcher := &iowrappers.PoiSearcher{}
-	PoiSearcher.Init(mapsClientApiKey, redisURL)
+	PoiSearcher.Init(mapsClientApiKey, redisURL, config.PoiSearcher)
 
-	planner.Solver.Init(PoiSearcher)
+	planner.Solver.Init(PoiSearcher, config.Solver)
+	planner.mapsClient = PoiSearcher.GetMapsClient()
 
 	planner.HomeHTMLTemplate = template.Must(template.ParseFiles("templates/index.html"))
 	planner.ResultHTMLTemplate = template.Must(template.ParseFiles("templates/plan_layout.html"))
@@ -98,11 +226,32 @@ func (planner *MyPlanner) Init(mapsClientApiKey string, redisURL *url.URL, redis
 func (planner *MyPlanner) Destroy() {
 	iowrappers.DestroyLogger()
 	planner.RedisClient.Destroy()
+	utils.CheckErrImmediate(planner.RequestRecorder.Close(), utils.LogError)
 }
 
-// single-day, single-city planning method
-func (planner *MyPlanner) Planning(req *solution.PlanningRequest, user string) (resp PlanningResponse) {
-	planningResp, err := planner.Solver.Solve(*req, planner.RedisClient)
+// single-day planning method
+// slots of a request can be located in different cities
+func (planner *MyPlanner) Planning(req *solution.PlanningRequest, requester Requester) (resp PlanningResponse) {
+	return planner.PlanningWithProgress(req, requester, nil)
+}
+
+// single-day planning method reporting progress of the solver
+func (planner *MyPlanner) PlanningWithProgress(req *solution.PlanningRequest, requester Requester, progress solution.ProgressReporter) (resp PlanningResponse) {
+	if len(req.SlotRequests) > 0 {
+		timeZone, timeZoneErr := planner.Solver.GetTimeZone(req.SlotRequests[0].Location)
+		if !utils.CheckErrImmediate(timeZoneErr, utils.LogError) {
+			resp.TimeZone = timeZone
+		}
+	}
+
+	if err := ValidatePlanningDate(req.Date, resp.TimeZone, time.Now()); err != nil {
+		resp.Err = err.Error()
+		resp.StatusCode = http.StatusBadRequest
+		return
+	}
+	planner.RequestRecorder.Record(NewCapturedRequest(req))
+
+	planningResp, err := planner.Solver.SolveWithProgress(*req, planner.RedisClient, progress)
 	utils.CheckErrImmediate(err, utils.LogError)
 	if err != nil {
 		resp.Err = err.Error()
@@ -111,18 +260,7 @@ func (planner *MyPlanner) Planning(req *solution.PlanningRequest, user string) (
 	}
 
 	// logging planning API usage for valid requests
-	if len(req.SlotRequests) > 0 {
-		countryCity := req.SlotRequests[0].Location
-		countryAndCity := strings.Split(countryCity, ",")
-		event := iowrappers.PlanningEvent{
-			User:      user,
-			Country:   countryAndCity[1],
-			City:      countryAndCity[0],
-			Timestamp: time.Now().Format(time.RFC3339),
-		}
-		planner.PlanningEvents <- event
-		planner.PlanningEventLogging(event)
-	}
+	planner.logPlanningEvent(req, requester)
 
 	if len(planningResp.Solutions) == 0 {
 		resp.Err = errors.New("cannot find a valid solution").Error()
@@ -130,33 +268,121 @@ func (planner *MyPlanner) Planning(req *solution.PlanningRequest, user string) (
 		return
 	}
 
+	resp.SetSolutions(req, planningResp)
+
+	// persist valid plans so that users can come back to them
+	// plans of the shared guest identity are temporary
+	var planExpiration time.Duration
+	if requester.Username == GuestUsername {
+		planExpiration = GuestPlanExpiration
+	}
+	planId, saveErr := planner.RedisClient.SavePlan(requester.Username, resp.TravelDestination, resp, planExpiration)
+	if !utils.CheckErrImmediate(saveErr, utils.LogError) {
+		resp.PlanID = planId
+	}
+	return
+}
+
+// fill the response with the places of the solutions found by the solver
+func (resp *PlanningResponse) SetSolutions(req *solution.PlanningRequest, planningResp solution.PlanningResponse) {
 	topSolutions := planningResp.Solutions
 	resp.Places = make([][]TimeSectionPlaces, len(topSolutions))
 	for sIdx, topSolution := range topSolutions {
-		for idx, slotSol := range topSolution.SlotSolutions {
-			timeSectionPlaces := TimeSectionPlaces{
-				Places: make([]TimeSectionPlace, 0),
-			}
-			for pIdx, placeName := range slotSol.PlaceNames {
-				timeSectionPlaces.Places = append(timeSectionPlaces.Places, TimeSectionPlace{
-					PlaceName: placeName,
-					StartTime: req.SlotRequests[idx].StayTimes[pIdx].Slot.Start,
-					EndTime:   req.SlotRequests[idx].StayTimes[pIdx].Slot.End,
-					Address:   slotSol.PlaceAddresses[pIdx],
-					URL:       slotSol.PlaceURLs[pIdx],
-				})
-			}
-			resp.Places[sIdx] = append(resp.Places[sIdx], timeSectionPlaces)
-		}
+		resp.Places[sIdx] = toTimeSectionPlaces(req, topSolution)
 	}
 
+	resp.TravelLegs = planningResp.TravelLegs
 	resp.StatusCode = solution.ValidSolutionFound
-	if len(req.SlotRequests) > 0 {
-		resp.TravelDestination = strings.Title(strings.Split(req.SlotRequests[0].Location, ",")[0])
-	} else {
-		resp.TravelDestination = "Dream Vacation Destination"
+	resp.TravelDestination = travelDestination(req)
+	resp.Weekday = req.Weekday
+	resp.Date = req.Date
+}
+
+// ValidatePlanningDate checks planning dates are not in the past of the local calendar of the destination
+// dates are not checked if the time zone of the destination is unknown
+func ValidatePlanningDate(date string, timeZone string, now time.Time) error {
+	if date == "" || timeZone == "" {
+		return nil
 	}
-	return
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil
+	}
+	planningDate, err := POI.ParseDate(date, location)
+	if err != nil {
+		return fmt.Errorf("invalid date %s, expected format is YYYY-MM-DD", date)
+	}
+	localNow := now.In(location)
+	today := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), 0, 0, 0, 0, location)
+	if planningDate.Before(today) {
+		return fmt.Errorf("date %s is in the past at the destination", date)
+	}
+	return nil
+}
+
+// travel destination consists of the distinct cities in the order of visit
+func travelDestination(req *solution.PlanningRequest) string {
+	cities := make([]string, 0)
+	for _, slotRequest := range req.SlotRequests {
+		city := strings.Title(strings.Split(slotRequest.Location, ",")[0])
+		if len(cities) == 0 || cities[len(cities)-1] != city {
+			cities = append(cities, city)
+		}
+	}
+	if len(cities) == 0 {
+		return "Dream Vacation Destination"
+	}
+	return strings.Join(cities, ", ")
+}
+
+func (planner *MyPlanner) logPlanningEvent(req *solution.PlanningRequest, requester Requester) {
+	if len(req.SlotRequests) == 0 {
+		return
+	}
+	countryCity := req.SlotRequests[0].Location
+	countryAndCity := strings.Split(countryCity, ",")
+	event := iowrappers.PlanningEvent{
+		User:      requester.Username,
+		APIKeyID:  requester.APIKeyID,
+		Country:   countryAndCity[1],
+		City:      countryAndCity[0],
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	planner.PlanningEvents <- event
+	iowrappers.PlanningEventsQueueDepth.Set(float64(len(planner.PlanningEvents)))
+	planner.PlanningEventLogging(event)
+}
+
+// convert a multi-slot solution to places with visiting times for each slot
+func toTimeSectionPlaces(req *solution.PlanningRequest, multiSlotSolution solution.MultiSlotSolution) []TimeSectionPlaces {
+	res := make([]TimeSectionPlaces, 0)
+	for idx, slotSol := range multiSlotSolution.SlotSolutions {
+		res = append(res, toSlotTimeSectionPlaces(req.SlotRequests[idx], slotSol))
+	}
+	return res
+}
+
+// convert a slot solution candidate to places with visiting times of the slot
+func toSlotTimeSectionPlaces(slotRequest solution.SlotRequest, slotSol solution.SlotSolutionCandidate) TimeSectionPlaces {
+	timeSectionPlaces := TimeSectionPlaces{
+		Places: make([]TimeSectionPlace, 0),
+	}
+	for pIdx, placeName := range slotSol.PlaceNames {
+		placeCategory := POI.PlaceCategoryVisit
+		if strings.ToUpper(string(slotRequest.EvOption[pIdx])) == "E" {
+			placeCategory = POI.PlaceCategoryEatery
+		}
+		timeSectionPlaces.Places = append(timeSectionPlaces.Places, TimeSectionPlace{
+			PlaceName: placeName,
+			Category:  placeCategory,
+			StartTime: slotRequest.StayTimes[pIdx].Slot.Start,
+			EndTime:   slotRequest.StayTimes[pIdx].Slot.End,
+			Address:   slotSol.PlaceAddresses[pIdx],
+			URL:       slotSol.PlaceURLs[pIdx],
+			Location:  slotSol.PlaceLocations[pIdx],
+		})
+	}
+	return timeSectionPlaces
 }
 
 // API definitions
@@ -166,101 +392,125 @@ func (planner *MyPlanner) indexPageHandler(c *gin.Context) {
 
 // HTTP POST API end-point
 func (planner *MyPlanner) postPlanningApi(c *gin.Context) {
-	var username = "guest" // default username
-	if planner.Environment == "production" {
-		var authenticationErr error
-		username, authenticationErr = planner.UserAuthentication(c.Request)
-		if authenticationErr != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": authenticationErr.Error()})
-			return
-		}
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
 	}
 
+	format := responseFormat(c)
+
 	req := PlanningPostRequest{}
 	err := c.ShouldBindJSON(&req)
 	utils.CheckErrImmediate(err, utils.LogInfo)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status_code": http.StatusBadRequest})
 		return
 	}
 
-	planningReq, err := processPlanningPostRequest(&req)
+	planningReq, err := planner.processPlanningPostRequest(&req)
 	utils.CheckErrImmediate(err, utils.LogInfo)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status_code": http.StatusBadRequest})
 		return
 	}
 
-	planningResp := planner.Planning(&planningReq, username)
-	if planningResp.Err != "" && planningResp.StatusCode == http.StatusNotFound {
+	planningResp := planner.Planning(&planningReq, planner.requester(c, username))
+	if format == ResponseFormatHTML && planningResp.Err != "" && planningResp.StatusCode == http.StatusNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No solution is found"})
 		return
 	}
 	// generate valid solution
-	utils.CheckErrImmediate(planner.ResultHTMLTemplate.Execute(c.Writer, planningResp), utils.LogError)
+	planner.renderPlanningResponse(c, format, planningResp)
 }
 
 // HTTP GET API end-point
 // Return top planning result to user
 func (planner *MyPlanner) getPlanningApi(c *gin.Context) {
-	var username = "guest" // default username
-	if strings.ToLower(planner.Environment) == "production" {
-		var authenticationErr error
-		username, authenticationErr = planner.UserAuthentication(c.Request)
-		if authenticationErr != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": authenticationErr.Error()})
-			return
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	format := responseFormat(c)
+
+	planningReq, err := planner.parsePlanningGetRequest(c, username)
+	if err != nil {
+		respondWithError(c, format, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	planningResp := planner.Planning(&planningReq, planner.requester(c, username))
+
+	errMsg := planningResp.Err
+	if errMsg != "" && format == ResponseFormatHTML {
+		if planningResp.StatusCode == solution.InvalidRequestLocation {
+			c.String(http.StatusBadRequest, errMsg)
+		} else if planningResp.StatusCode == solution.NoValidSolution {
+			errString := "No valid solution is found.\n Please try to search with larger radius."
+			c.String(http.StatusBadRequest, errString)
 		}
+		return
 	}
 
+	planner.renderPlanningResponse(c, format, planningResp)
+}
+
+// parse and validate query parameters of planning GET requests
+// slots of the day are generated from a built-in template or a template of the user
+func (planner *MyPlanner) parsePlanningGetRequest(c *gin.Context, username string) (planningReq solution.PlanningRequest, err error) {
 	country := c.DefaultQuery("country", "USA")
 	city := c.DefaultQuery("city", "San Diego")
 	radius := c.DefaultQuery("radius", "10000")
-	weekday := c.DefaultQuery("weekday", "5") // Saturday
+	date := c.Query("date")
+	weekday := c.DefaultQuery("weekday", "5") // deprecated, Saturday
 	numResults := c.DefaultQuery("numberResults", "5")
+	templateName := c.DefaultQuery("template", solution.StandardTemplateName)
 
 	numResultsInt, numResultsParsingErr := strconv.ParseUint(numResults, 10, 64)
 	if numResultsParsingErr != nil {
-		c.String(http.StatusBadRequest, "number of planning results of %d is invalid", numResultsInt)
+		err = fmt.Errorf("number of planning results of %s is invalid", numResults)
 		return
 	}
 	iowrappers.Logger.Debugf("number of requested planning results is %s", numResults)
 
 	weekdayUint, weekdayParsingErr := strconv.ParseUint(weekday, 10, 8)
-	if weekdayParsingErr != nil || weekdayUint < 0 || weekdayUint > 6 {
-		c.String(http.StatusBadRequest, "invalid weekday of %d", weekdayUint)
+	if date == "" && (weekdayParsingErr != nil || weekdayUint < 0 || weekdayUint > 6) {
+		err = fmt.Errorf("invalid weekday of %s", weekday)
+		return
+	}
+
+	planningWeekday, err := weekdayOfDate(date, POI.Weekday(weekdayUint))
+	if err != nil {
 		return
 	}
 
 	if !validateSearchRadius(radius) {
-		c.String(http.StatusBadRequest, "invalid search radius of %s", radius)
+		err = fmt.Errorf("invalid search radius of %s", radius)
+		return
+	}
+
+	template, err := planner.findDayTemplate(username, templateName)
+	if err != nil {
+		err = fmt.Errorf("template %s does not exist", templateName)
 		return
 	}
 
 	cityCountry := city + "," + country
 
-	planningReq := solution.GetStandardRequest(POI.Weekday(weekdayUint), numResultsInt)
+	planningReq = template.PlanningRequest(cityCountry, planningWeekday, numResultsInt)
+	planningReq.Date = date
 	searchRadius_, _ := strconv.ParseUint(radius, 10, 32)
 	planningReq.SearchRadius = uint(searchRadius_)
+	return
+}
 
-	for slotReqIdx := range planningReq.SlotRequests {
-		planningReq.SlotRequests[slotReqIdx].Location = cityCountry // set to the same location from URL
-	}
-
-	planningResp := planner.Planning(&planningReq, username)
-
-	err := planningResp.Err
-	if err != "" {
-		if planningResp.StatusCode == solution.InvalidRequestLocation {
-			c.String(http.StatusBadRequest, err)
-		} else if planningResp.StatusCode == solution.NoValidSolution {
-			errString := "No valid solution is found.\n Please try to search with larger radius."
-			c.String(http.StatusBadRequest, errString)
-		}
+// respond with an error message in the requested format
+func respondWithError(c *gin.Context, format string, statusCode int, errMsg string) {
+	if format != ResponseFormatHTML {
+		c.JSON(statusCode, gin.H{"error": errMsg, "status_code": statusCode})
 		return
 	}
-
-	utils.CheckErrImmediate(planner.ResultHTMLTemplate.Execute(c.Writer, planningResp), utils.LogError)
+	c.String(statusCode, errMsg)
 }
 
 func (planner MyPlanner) SetupRouter(serverPort string) *http.Server {
@@ -269,24 +519,70 @@ func (planner MyPlanner) SetupRouter(serverPort string) *http.Server {
 		gin.SetMode(gin.DebugMode)
 	}
 	gin.DefaultWriter = ioutil.Discard
+	planner.Config = planner.Config.orDefaults()
 
 	myRouter := gin.Default()
+	myRouter.Use(requestMetrics())
+
+	planner.mapsKeyCheck = &cachedDependencyCheck{}
 
 	myRouter.GET("", planner.indexPageHandler)
+	myRouter.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	myRouter.GET("/healthz", planner.livenessApi)
+	myRouter.GET("/readyz", planner.readinessApi)
 
 	v1 := myRouter.Group("/v1")
+	v1.Use(planner.rateLimiter())
 	{
 		v1.GET("/plans", planner.getPlanningApi)
 		v1.POST("/plans", planner.postPlanningApi)
+		v1.GET("/plans/:id", planner.getSavedPlanApi)
+		v1.DELETE("/plans/:id", planner.deleteSavedPlanApi)
+		v1.POST("/plans/:id/shares", planner.createPlanShareApi)
+		v1.DELETE("/plans/:id/shares/:token", planner.revokePlanShareApi)
+		v1.GET("/shared/:token", planner.getSharedPlanApi)
+		v1.GET("/users/me/plans", planner.listSavedPlansApi)
+		v1.POST("/users/me/api-keys", planner.createAPIKeyApi)
+		v1.GET("/users/me/api-keys", planner.listAPIKeysApi)
+		v1.DELETE("/users/me/api-keys/:id", planner.revokeAPIKeyApi)
+		v1.POST("/trips", planner.postTripPlanningApi)
+		v1.GET("/templates", planner.listDayTemplatesApi)
+		v1.POST("/templates", planner.saveDayTemplateApi)
+		v1.GET("/templates/:name", planner.getDayTemplateApi)
+		v1.DELETE("/templates/:name", planner.deleteDayTemplateApi)
+		v1.GET("/plan-events", planner.streamPlanningApi)
+		v1.POST("/plan-events", planner.streamPlanningApi)
+		v1.POST("/plan-jobs", planner.postPlanJobApi)
+		v1.GET("/plan-jobs/:id", planner.getPlanJobApi)
+		v1.GET("/admin/rate-limit-exemptions", planner.listRateLimitExemptionsApi)
+		v1.PUT("/admin/rate-limit-exemptions/:username", planner.rateLimitExemptionApi)
+		v1.DELETE("/admin/rate-limit-exemptions/:username", planner.rateLimitExemptionApi)
+		v1.GET("/admin/users", planner.listUsersApi)
+		v1.GET("/admin/users/:username", planner.getUserApi)
+		v1.PUT("/admin/users/:username/level", planner.setUserLevelApi)
+		v1.POST("/admin/users/:username/disable", planner.setUserDisabledApi(true))
+		v1.POST("/admin/users/:username/enable", planner.setUserDisabledApi(false))
+		v1.DELETE("/admin/users/:username", planner.deleteUserApi)
+		v1.GET("/admin/analytics/visitors", planner.visitorsAnalyticsApi)
+		v1.GET("/admin/analytics/cities", planner.citiesAnalyticsApi)
+		v1.GET("/admin/analytics/volume", planner.volumeAnalyticsApi)
+		v1.GET("/admin/analytics/users", planner.usersAnalyticsApi)
 		v1.POST("/signup", planner.UserSignup)
 		v1.POST("/login", planner.UserLogin)
+		v1.POST("/logout", planner.UserLogout)
+		v1.POST("/token/refresh", planner.refreshTokenApi)
+		v1.GET("/email/verify", planner.verifyEmailApi)
+		v1.POST("/email/verification", planner.resendVerificationEmailApi)
+		v1.POST("/password/forgot", planner.forgotPasswordApi)
+		v1.GET("/password/reset", planner.passwordResetPageApi)
+		v1.POST("/password/reset", planner.resetPasswordApi)
 	}
 
 	svr := &http.Server{
 		Addr:         ":" + serverPort,
-		Handler:      myRouter,
-		ReadTimeout:  ServerTimeout,
-		WriteTimeout: ServerTimeout,
+		Handler:      StreamingHandler(myRouter),
+		ReadTimeout:  planner.Config.ServerTimeout,
+		WriteTimeout: planner.Config.ServerTimeout,
 	}
 
 	return svr
@@ -0,0 +1,287 @@
+package planner
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxAnalyticsWindow     = 30 * 24 * time.Hour
+	MaxAnalyticsEvents     = 100000
+	DefaultTopCities       = 10
+	DefaultTopUsers        = 20
+	MaxAnalyticsResults    = 100
+	numTopCitiesOfUser     = 3
+	defaultAnalyticsWindow = "24h"
+)
+
+type CityActivity struct {
+	Country     string `json:"country"`
+	City        string `json:"city"`
+	Requests    int    `json:"requests"`
+	UniqueUsers int    `json:"unique_users"`
+}
+
+type HourlyVolume struct {
+	Hour        time.Time `json:"hour"`
+	Requests    int       `json:"requests"`
+	UniqueUsers int       `json:"unique_users"`
+}
+
+type UserActivity struct {
+	Username       string         `json:"username"`
+	Requests       int            `json:"requests"`
+	APIKeyRequests int            `json:"api_key_requests"`
+	NumCities      int            `json:"num_cities"`
+	TopCities      []CityActivity `json:"top_cities"`
+	FirstRequestAt time.Time      `json:"first_request_at"`
+	LastRequestAt  time.Time      `json:"last_request_at"`
+}
+
+type UniqueVisitors struct {
+	Window         string `json:"window"`
+	UniqueVisitors int64  `json:"unique_visitors"`
+}
+
+// parse time windows such as 90m, 24h and 7d
+func parseAnalyticsWindow(window string) (time.Duration, error) {
+	var duration time.Duration
+	var err error
+	if strings.HasSuffix(window, "d") {
+		var days int
+		days, err = strconv.Atoi(strings.TrimSuffix(window, "d"))
+		duration = time.Duration(days) * 24 * time.Hour
+	} else {
+		duration, err = time.ParseDuration(window)
+	}
+	if err != nil || duration <= 0 || duration > MaxAnalyticsWindow {
+		return 0, errors.New("invalid window " + window + ", windows are at most 30d, e.g. 24h or 7d")
+	}
+	return duration, nil
+}
+
+func parseAnalyticsLimit(c *gin.Context, defaultLimit int) (int, error) {
+	limit, err := strconv.Atoi(c.DefaultQuery("n", strconv.Itoa(defaultLimit)))
+	if err != nil || limit < 1 || limit > MaxAnalyticsResults {
+		return 0, errors.New("n must be an integer between 1 and " + strconv.Itoa(MaxAnalyticsResults))
+	}
+	return limit, nil
+}
+
+// count requests and unique users of each city, ordered by number of requests
+func TopCities(entries []iowrappers.PlanningStreamEntry, n int) []CityActivity {
+	cities := make(map[[2]string]*CityActivity)
+	users := make(map[[2]string]map[string]bool)
+	for _, entry := range entries {
+		key := [2]string{entry.Country, entry.City}
+		if _, exist := cities[key]; !exist {
+			cities[key] = &CityActivity{Country: entry.Country, City: entry.City}
+			users[key] = make(map[string]bool)
+		}
+		cities[key].Requests++
+		users[key][entry.User] = true
+	}
+
+	res := make([]CityActivity, 0, len(cities))
+	for key, city := range cities {
+		city.UniqueUsers = len(users[key])
+		res = append(res, *city)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Requests != res[j].Requests {
+			return res[i].Requests > res[j].Requests
+		}
+		if res[i].Country != res[j].Country {
+			return res[i].Country < res[j].Country
+		}
+		return res[i].City < res[j].City
+	})
+	if len(res) > n {
+		res = res[:n]
+	}
+	return res
+}
+
+// count requests and unique users of each hour in [start, end), hours without requests are included
+func HourlyRequestVolume(entries []iowrappers.PlanningStreamEntry, start time.Time, end time.Time) []HourlyVolume {
+	res := make([]HourlyVolume, 0)
+	hourIndexes := make(map[time.Time]int)
+	for hour := start.UTC().Truncate(time.Hour); hour.Before(end); hour = hour.Add(time.Hour) {
+		hourIndexes[hour] = len(res)
+		res = append(res, HourlyVolume{Hour: hour})
+	}
+
+	users := make([]map[string]bool, len(res))
+	for _, entry := range entries {
+		idx, exist := hourIndexes[entry.Time.UTC().Truncate(time.Hour)]
+		if !exist {
+			continue
+		}
+		res[idx].Requests++
+		if users[idx] == nil {
+			users[idx] = make(map[string]bool)
+		}
+		users[idx][entry.User] = true
+	}
+	for idx := range res {
+		res[idx].UniqueUsers = len(users[idx])
+	}
+	return res
+}
+
+// summarize requests of each user, ordered by number of requests
+func UserActivities(entries []iowrappers.PlanningStreamEntry, n int) []UserActivity {
+	entriesOfUsers := make(map[string][]iowrappers.PlanningStreamEntry)
+	for _, entry := range entries {
+		entriesOfUsers[entry.User] = append(entriesOfUsers[entry.User], entry)
+	}
+
+	res := make([]UserActivity, 0, len(entriesOfUsers))
+	for username, userEntries := range entriesOfUsers {
+		activity := UserActivity{
+			Username:       username,
+			Requests:       len(userEntries),
+			FirstRequestAt: userEntries[0].Time,
+			LastRequestAt:  userEntries[0].Time,
+		}
+		for _, entry := range userEntries {
+			if entry.APIKeyID != "" {
+				activity.APIKeyRequests++
+			}
+			if entry.Time.Before(activity.FirstRequestAt) {
+				activity.FirstRequestAt = entry.Time
+			}
+			if entry.Time.After(activity.LastRequestAt) {
+				activity.LastRequestAt = entry.Time
+			}
+		}
+		cities := TopCities(userEntries, len(userEntries))
+		activity.NumCities = len(cities)
+		activity.TopCities = cities[:utils.MinInt(numTopCitiesOfUser, len(cities))]
+		res = append(res, activity)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Requests != res[j].Requests {
+			return res[i].Requests > res[j].Requests
+		}
+		return res[i].Username < res[j].Username
+	})
+	if len(res) > n {
+		res = res[:n]
+	}
+	return res
+}
+
+// read planning events in the window of the request
+func (planner *MyPlanner) analyticsEvents(c *gin.Context) (entries []iowrappers.PlanningStreamEntry, start time.Time, end time.Time, ok bool) {
+	window, err := parseAnalyticsWindow(c.DefaultQuery("window", defaultAnalyticsWindow))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	end = time.Now().UTC()
+	start = end.Add(-window)
+
+	entries, truncated, err := planner.RedisClient.ReadPlanningEvents(planner.RedisStreamName, start, end, MaxAnalyticsEvents)
+	if utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read planning events"})
+		return
+	}
+	c.Header("X-Events-Truncated", strconv.FormatBool(truncated))
+	return entries, start, end, true
+}
+
+// HTTP GET API end-point for unique visitors of the planning APIs in time windows
+// counts are from hourly HyperLogLogs, so windows are rounded up to whole hours
+func (planner *MyPlanner) visitorsAnalyticsApi(c *gin.Context) {
+	if _, ok := planner.authenticateAdmin(c); !ok {
+		return
+	}
+
+	end := time.Now().UTC()
+	res := make([]UniqueVisitors, 0)
+	for _, window := range strings.Split(c.DefaultQuery("windows", "1h,24h,7d"), ",") {
+		window = strings.TrimSpace(window)
+		duration, err := parseAnalyticsWindow(window)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		count, err := planner.RedisClient.CountUniqueVisitors(end.Add(-duration), end)
+		if utils.CheckErrImmediate(err, utils.LogError) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count unique visitors"})
+			return
+		}
+		res = append(res, UniqueVisitors{Window: window, UniqueVisitors: count})
+	}
+
+	total, err := planner.RedisClient.CountAllUniqueVisitors()
+	if utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count unique visitors"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"windows": res, "total_unique_visitors": total})
+}
+
+// HTTP GET API end-point for the most requested cities, which are the candidates for pre-warming
+func (planner *MyPlanner) citiesAnalyticsApi(c *gin.Context) {
+	if _, ok := planner.authenticateAdmin(c); !ok {
+		return
+	}
+	n, err := parseAnalyticsLimit(c, DefaultTopCities)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entries, start, end, ok := planner.analyticsEvents(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"start": start, "end": end, "requests": len(entries), "cities": TopCities(entries, n)})
+}
+
+// HTTP GET API end-point for the number of planning requests in each hour
+func (planner *MyPlanner) volumeAnalyticsApi(c *gin.Context) {
+	if _, ok := planner.authenticateAdmin(c); !ok {
+		return
+	}
+	entries, start, end, ok := planner.analyticsEvents(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"start": start, "end": end, "requests": len(entries), "hours": HourlyRequestVolume(entries, start, end)})
+}
+
+// HTTP GET API end-point for the planning activity of the most active users
+func (planner *MyPlanner) usersAnalyticsApi(c *gin.Context) {
+	if _, ok := planner.authenticateAdmin(c); !ok {
+		return
+	}
+	n, err := parseAnalyticsLimit(c, DefaultTopUsers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entries, start, end, ok := planner.analyticsEvents(c)
+	if !ok {
+		return
+	}
+	// the stream stores values in lowercase
+	if username := strings.ToLower(c.Query("username")); username != "" {
+		userEntries := make([]iowrappers.PlanningStreamEntry, 0)
+		for _, entry := range entries {
+			if entry.User == username {
+				userEntries = append(userEntries, entry)
+			}
+		}
+		entries = userEntries
+	}
+	c.JSON(http.StatusOK, gin.H{"start": start, "end": end, "requests": len(entries), "users": UserActivities(entries, n)})
+}
@@ -0,0 +1,115 @@
+package planner
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	MaxProgressCandidates = 3 // number of slot candidates previewed in progress events
+	ProgressResult        = "result"
+	ProgressError         = "error"
+	ProgressPath          = "/v1/plan-events"
+)
+
+// serve progress streams without the write timeout of the server
+// progress streams last as long as the planning, which may take longer than the server timeout
+func StreamingHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == ProgressPath {
+			// writers of test recorders do not support deadlines
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// candidates of a slot ready before the whole plan is solved
+// places of the candidates are ordered by descending score
+type SlotCandidatesProgress struct {
+	Slot       int                 `json:"slot"`
+	Location   string              `json:"location"`
+	FromCache  bool                `json:"from_cache"`
+	Candidates []TimeSectionPlaces `json:"candidates"`
+}
+
+// preview the best candidates of a slot with the visiting times of the slot request
+func toSlotCandidatesProgress(req *solution.PlanningRequest, event solution.ProgressEvent) SlotCandidatesProgress {
+	candidates := make([]solution.SlotSolutionCandidate, len(event.Candidates))
+	copy(candidates, event.Candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	if len(candidates) > MaxProgressCandidates {
+		candidates = candidates[:MaxProgressCandidates]
+	}
+
+	progress := SlotCandidatesProgress{
+		Slot:       event.Slot,
+		Location:   event.Location,
+		FromCache:  event.FromCache,
+		Candidates: make([]TimeSectionPlaces, 0),
+	}
+	for _, candidate := range candidates {
+		progress.Candidates = append(progress.Candidates, toSlotTimeSectionPlaces(req.SlotRequests[event.Slot], candidate))
+	}
+	return progress
+}
+
+// HTTP GET and POST API end-point streaming planning progress as Server-Sent Events
+// GET requests take the same query parameters as the planning GET API and work with EventSource
+// POST requests take the same body as the planning POST API
+// the last event is either the planning result or an error
+func (planner *MyPlanner) streamPlanningApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	var planningReq solution.PlanningRequest
+	var err error
+	if c.Request.Method == http.MethodPost {
+		req := PlanningPostRequest{}
+		if err = c.ShouldBindJSON(&req); err == nil {
+			planningReq, err = planner.processPlanningPostRequest(&req)
+		}
+	} else {
+		planningReq, err = planner.parsePlanningGetRequest(c, username)
+	}
+	utils.CheckErrImmediate(err, utils.LogInfo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status_code": http.StatusBadRequest})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable response buffering of reverse proxies
+	sendEvent := func(name string, data interface{}) {
+		c.SSEvent(name, data)
+		c.Writer.Flush()
+	}
+
+	progress := func(event solution.ProgressEvent) {
+		// stop reporting progress once the client is gone, planning continues so that the plan is saved
+		if c.Request.Context().Err() != nil {
+			return
+		}
+		if event.Type == solution.ProgressSlotCandidates {
+			sendEvent(event.Type, toSlotCandidatesProgress(&planningReq, event))
+			return
+		}
+		sendEvent(event.Type, event)
+	}
+
+	planningResp := planner.PlanningWithProgress(&planningReq, planner.requester(c, username), progress)
+	if planningResp.Err != "" {
+		sendEvent(ProgressError, gin.H{"error": planningResp.Err, "status_code": planningResp.StatusCode})
+		return
+	}
+	sendEvent(ProgressResult, planningResp)
+}
@@ -6,21 +6,66 @@ import (
 	"github.com/weihesdlegend/Vacation-planner/POI"
 	"github.com/weihesdlegend/Vacation-planner/matching"
 	"github.com/weihesdlegend/Vacation-planner/solution"
+	"github.com/weihesdlegend/Vacation-planner/utils"
 	"strings"
+	"time"
 )
 
-func processPlanningPostRequest(req *PlanningPostRequest) (planningRequest solution.PlanningRequest, err error) {
+// derive the weekday from a YYYY-MM-DD date
+// the deprecated weekday is used if the date is not provided
+func weekdayOfDate(date string, weekday POI.Weekday) (POI.Weekday, error) {
+	if date == "" {
+		return weekday, nil
+	}
+	// a calendar date falls on the same weekday in all time zones
+	planningDate, err := POI.ParseDate(date, time.UTC)
+	if err != nil {
+		return weekday, fmt.Errorf("invalid date %s, expected format is YYYY-MM-DD", date)
+	}
+	return POI.GetWeekday(planningDate), nil
+}
+
+func (planner *MyPlanner) processPlanningPostRequest(req *PlanningPostRequest) (solution.PlanningRequest, error) {
+	return ProcessPlanningPostRequest(req, planner.Config)
+}
+
+// validate a planning POST request and generate slot requests of the solver
+func ProcessPlanningPostRequest(req *PlanningPostRequest, config PlannerConfig) (planningRequest solution.PlanningRequest, err error) {
+	config = config.orDefaults()
+	req.Weekday, err = weekdayOfDate(req.Date, req.Weekday)
+	if err != nil {
+		return
+	}
 	if req.Weekday > POI.DateSunday || req.Weekday < POI.DateMonday {
 		err = errors.New("invalid weekday in the request")
 		return
 	}
 
 	planningRequest.Weekday = req.Weekday
+	planningRequest.Date = req.Date
 	planningRequest.SearchRadius = 10000
+
+	if len(req.Stops) > 0 {
+		planningRequest.SlotRequests, err = genMultiCitySlotRequests(req, config)
+		return
+	}
+
 	// basic POST parameter validations
+	setPostReqDefaults(req)
+
+	err = checkPostReqTimePlaceNum(req, config.MaxPlacesPerDay)
+	if err != nil {
+		return
+	}
+
+	planningRequest.SlotRequests = GenSlotRequests(*req, config.MaxPlacesPerSlot)
+	return
+}
+
+func setPostReqDefaults(req *PlanningPostRequest) {
 	if req.StartTime == 0 || req.EndTime == 0 {
-		req.StartTime = 9
-		req.EndTime = 22
+		req.StartTime = POI.NewTimeOfDay(9, 0)
+		req.EndTime = POI.NewTimeOfDay(22, 0)
 	}
 
 	if req.NumEatery == 0 {
@@ -30,17 +75,51 @@ func processPlanningPostRequest(req *PlanningPostRequest) (planningRequest solut
 	if req.NumVisit == 0 {
 		req.NumVisit = 2
 	}
+}
 
-	err = checkPostReqTimePlaceNum(req)
-	if err != nil {
-		return
-	}
+// generate slot requests for each city in the itinerary
+// cities are visited in order and their time ranges cannot overlap
+func genMultiCitySlotRequests(req *PlanningPostRequest, config PlannerConfig) (slotRequests []solution.SlotRequest, err error) {
+	var numPlaces uint
+	var previousEndTime POI.TimeOfDay
+	for idx, stop := range req.Stops {
+		if strings.TrimSpace(stop.City) == "" || strings.TrimSpace(stop.Country) == "" {
+			err = fmt.Errorf("city and country are required for stop %d", idx+1)
+			return
+		}
+		stopReq := PlanningPostRequest{
+			Country:   stop.Country,
+			City:      stop.City,
+			Weekday:   req.Weekday,
+			StartTime: stop.StartTime,
+			EndTime:   stop.EndTime,
+			NumVisit:  stop.NumVisit,
+			NumEatery: stop.NumEatery,
+		}
+		// stops are compared with their time ranges after the defaults are applied
+		setPostReqDefaults(&stopReq)
+		if idx > 0 && stopReq.StartTime < previousEndTime {
+			err = fmt.Errorf("stop %d cannot start before the previous stop ends", idx+1)
+			return
+		}
+		previousEndTime = stopReq.EndTime
+		if err = checkPostReqTimePlaceNum(&stopReq, config.MaxPlacesPerDay); err != nil {
+			err = fmt.Errorf("stop %d: %s", idx+1, err.Error())
+			return
+		}
 
-	planningRequest.SlotRequests = GenSlotRequests(*req)
+		numPlaces += stopReq.NumVisit + stopReq.NumEatery
+		if numPlaces > uint(config.MaxPlacesPerDay) {
+			err = fmt.Errorf("total number of places cannot exceed %d", config.MaxPlacesPerDay)
+			return
+		}
+		slotRequests = append(slotRequests, GenSlotRequests(stopReq, config.MaxPlacesPerSlot)...)
+	}
 	return
 }
 
-func GenSlotRequests(req PlanningPostRequest) []solution.SlotRequest {
+// groups are combined if they have at most maxPlacesPerSlot places
+func GenSlotRequests(req PlanningPostRequest, maxPlacesPerSlot int) []solution.SlotRequest {
 	// grouping
 	numGroups := uint(1)
 	numVisit, numEatery := req.NumVisit, req.NumEatery
@@ -76,19 +155,21 @@ func GenSlotRequests(req PlanningPostRequest) []solution.SlotRequest {
 	}
 
 	// time allocation
-	numHours := int(req.EndTime - req.StartTime)
-	hours := make([]int, numGroups)
+	// each place takes at least one hour, the rest of the time is allocated hour by hour to the groups in turn
+	numMinutes := int(req.EndTime) - int(req.StartTime)
+	minutes := make([]int, numGroups)
 
-	for idx := range hours {
-		hours[idx] = len(groups[idx])
-		numHours -= hours[idx]
+	for idx := range minutes {
+		minutes[idx] = len(groups[idx]) * POI.MinutesPerHour
+		numMinutes -= minutes[idx]
 	}
 
 	groupIdx := 0
-	for numHours > 0 {
-		hours[groupIdx] += 1
+	for numMinutes > 0 {
+		allocatedMinutes := utils.MinInt(POI.MinutesPerHour, numMinutes)
+		minutes[groupIdx] += allocatedMinutes
 		groupIdx++
-		numHours--
+		numMinutes -= allocatedMinutes
 		if groupIdx == len(groups) {
 			groupIdx = 0
 		}
@@ -103,17 +184,17 @@ func GenSlotRequests(req PlanningPostRequest) []solution.SlotRequest {
 		slotRequests[groupIdx].Location = cityCountry
 		slotRequests[groupIdx].EvOption = strings.Join(groups[groupIdx], "")
 		slotRequests[groupIdx].StayTimes = make([]matching.TimeSlot, len(groups[groupIdx]))
-		allocatedTime := hours[groupIdx]
+		allocatedTime := minutes[groupIdx]
 		for placeIdx, placeType := range groups[groupIdx] {
 			curSlot := matching.TimeSlot{}
 			if placeType == "E" {
 				curSlot.Slot.Start = curTime
-				curSlot.Slot.End = curTime + 1
-				allocatedTime -= 1
-				curTime += 1
+				curSlot.Slot.End = curTime + POI.MinutesPerHour
+				allocatedTime -= POI.MinutesPerHour
+				curTime += POI.MinutesPerHour
 			} else {
 				curSlot.Slot.Start = curTime
-				curTime += POI.Hour(allocatedTime)
+				curTime += POI.TimeOfDay(allocatedTime)
 				curSlot.Slot.End = curTime
 			}
 			slotRequests[groupIdx].StayTimes[placeIdx] = curSlot
@@ -126,7 +207,7 @@ func GenSlotRequests(req PlanningPostRequest) []solution.SlotRequest {
 
 	// combine groups and limit maximum number of groups
 	for numGroups > 3 && curGroupIdx < len(slotRequests) {
-		if len(slotRequests[groupIdx].EvOption)+len(slotRequests[curGroupIdx].EvOption) <= MaxPlacesPerSlot {
+		if len(slotRequests[groupIdx].EvOption)+len(slotRequests[curGroupIdx].EvOption) <= maxPlacesPerSlot {
 			slotRequests[groupIdx].EvOption = slotRequests[groupIdx].EvOption + slotRequests[curGroupIdx].EvOption
 			slotRequests[groupIdx].StayTimes = append(slotRequests[groupIdx].StayTimes, slotRequests[curGroupIdx].StayTimes...)
 			excludedGroupIndexes[curGroupIdx] = true
@@ -147,9 +228,9 @@ func GenSlotRequests(req PlanningPostRequest) []solution.SlotRequest {
 	return finalRes
 }
 
-func checkPostReqTimePlaceNum(req *PlanningPostRequest) (err error) {
-	if req.StartTime > 24 || req.EndTime > 24 {
-		err = errors.New("invalid time, valid times are chosen from 1-24")
+func checkPostReqTimePlaceNum(req *PlanningPostRequest, maxPlacesPerDay int) (err error) {
+	if req.StartTime > POI.EndOfDay || req.EndTime > POI.EndOfDay {
+		err = errors.New("invalid time, valid times are chosen from 00:00-24:00")
 		return
 	}
 	if req.StartTime >= req.EndTime {
@@ -157,12 +238,13 @@ func checkPostReqTimePlaceNum(req *PlanningPostRequest) (err error) {
 		return
 	}
 
-	if req.NumEatery+req.NumVisit > MaxPlacesPerDay {
-		err = fmt.Errorf("total number of places cannot exceed %d", MaxPlacesPerDay)
+	if req.NumEatery+req.NumVisit > uint(maxPlacesPerDay) {
+		err = fmt.Errorf("total number of places cannot exceed %d", maxPlacesPerDay)
 		return
 	}
 
-	if req.NumEatery+req.NumVisit > uint(req.EndTime-req.StartTime) {
+	// each place takes at least one hour
+	if (req.NumEatery+req.NumVisit)*POI.MinutesPerHour > uint(req.EndTime-req.StartTime) {
 		err = errors.New("not enough time for visiting all the places")
 	}
 	return
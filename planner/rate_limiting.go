@@ -0,0 +1,201 @@
+package planner
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/user"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RateLimitScopeUser     = "user"
+	RateLimitScopeIP       = "ip"
+	RateLimitScopeEndpoint = "endpoint"
+)
+
+// limits on the number of requests in a fixed time window
+// a limit of zero disables the corresponding rate limit
+type RateLimitConfig struct {
+	Window         time.Duration
+	UserLimit      int64            // per authenticated user
+	GuestLimit     int64            // per IP address for users not logged in
+	EndpointLimits map[string]int64 // per user or IP address for each endpoint, keys are in the form of "POST /v1/plans"
+	// IP addresses or CIDR ranges of reverse proxies trusted to set the X-Forwarded-For and X-Real-IP headers
+	// guests are identified by the address of the connection if the connection does not come from a trusted proxy
+	TrustedProxies []string
+}
+
+// planning endpoints may call Google Maps APIs on cold cache and are limited more strictly
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Window:     time.Minute,
+		UserLimit:  60,
+		GuestLimit: 20,
+		EndpointLimits: map[string]int64{
+			"GET /v1/plans":        10,
+			"POST /v1/plans":       10,
+			"POST /v1/trips":       2,
+			"POST /v1/plan-jobs":   10,
+			"GET /v1/plan-events":  10,
+			"POST /v1/plan-events": 10,
+		},
+	}
+}
+
+// gin middleware enforcing rate limits with counters in Redis shared by all server instances
+// requests are allowed if Redis is not available
+func (planner *MyPlanner) rateLimiter() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		config := planner.RateLimits
+		if config.Window <= 0 {
+			c.Next()
+			return
+		}
+
+		scope, identity, limit := RateLimitScopeIP, guestIP(c, config.TrustedProxies), config.GuestLimit
+		if username, err := planner.UserAuthentication(c.Request); err == nil {
+			exempt, exemptErr := planner.RedisClient.IsRateLimitExempt(username)
+			utils.CheckErrImmediate(exemptErr, utils.LogError)
+			if exempt {
+				c.Next()
+				return
+			}
+			scope, identity, limit = RateLimitScopeUser, username, config.UserLimit
+		}
+
+		if !planner.allowRequest(c, scope, identity, limit, config.Window) {
+			return
+		}
+
+		endpoint := c.Request.Method + " " + c.FullPath()
+		if endpointLimit, exists := config.EndpointLimits[endpoint]; exists {
+			if !planner.allowRequest(c, RateLimitScopeEndpoint, endpoint+":"+scope+":"+identity, endpointLimit, config.Window) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// forwarded headers can be set by any client and are only used for requests from trusted proxies
+// proxies append addresses to X-Forwarded-For, so the header is read from the right
+// and the first address that is not a trusted proxy is the client
+func guestIP(c *gin.Context, trustedProxies []string) string {
+	clientIP, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		clientIP = strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	if !isTrustedProxy(net.ParseIP(clientIP), trustedProxies) {
+		return clientIP
+	}
+
+	forwardedFor := strings.Split(strings.Join(c.Request.Header["X-Forwarded-For"], ","), ",")
+	for idx := len(forwardedFor) - 1; idx >= 0; idx-- {
+		ip := net.ParseIP(strings.TrimSpace(forwardedFor[idx]))
+		if ip == nil {
+			break
+		}
+		clientIP = ip.String()
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+	return clientIP
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []string) bool {
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if _, ipNet, err := net.ParseCIDR(proxy); err == nil {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		} else if proxyIP := net.ParseIP(proxy); proxyIP != nil && proxyIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// count the request and abort it with too many requests status if the limit is exceeded
+func (planner *MyPlanner) allowRequest(c *gin.Context, scope string, identity string, limit int64, window time.Duration) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	count, ttl, err := planner.RedisClient.IncrementRateLimitCounter(scope, identity, window)
+	if utils.CheckErrImmediate(err, utils.LogError) {
+		return true
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+	remaining := limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+	if count <= limit {
+		return true
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(ttl.Seconds()))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded, please try again later"})
+	return false
+}
+
+// returns the username of the current user if the user is an admin
+// admins always need to log in, even in non-production environments
+func (planner *MyPlanner) authenticateAdmin(c *gin.Context) (username string, ok bool) {
+	username, err := planner.UserAuthentication(c.Request)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return "", false
+	}
+	currentUser, err := planner.RedisClient.FindUser(username)
+	if err != nil || currentUser.UserLevel != user.LevelAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "operation forbidden, admin access is required"})
+		return "", false
+	}
+	return username, true
+}
+
+// HTTP GET API end-point for users exempted from rate limits
+func (planner *MyPlanner) listRateLimitExemptionsApi(c *gin.Context) {
+	if _, ok := planner.authenticateAdmin(c); !ok {
+		return
+	}
+
+	usernames, err := planner.RedisClient.ListRateLimitExemptUsers()
+	if utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list exempted users"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": usernames})
+}
+
+// HTTP PUT and DELETE API end-point for exempting an user from rate limits and revoking the exemption
+func (planner *MyPlanner) rateLimitExemptionApi(c *gin.Context) {
+	if _, ok := planner.authenticateAdmin(c); !ok {
+		return
+	}
+
+	username := c.Param("username")
+	if _, err := planner.RedisClient.FindUser(username); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	exempt := c.Request.Method == http.MethodPut
+	if utils.CheckErrImmediate(planner.RedisClient.SetRateLimitExemption(username, exempt), utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update rate limit exemption"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"username": username, "exempt": exempt})
+}
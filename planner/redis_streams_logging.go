@@ -14,11 +14,15 @@ func (planner MyPlanner) PlanningEventLogging(event iowrappers.PlanningEvent) {
 		"country":   event.Country,
 		"timestamp": event.Timestamp,
 	}
+	if event.APIKeyID != "" {
+		eventData["api_key_id"] = event.APIKeyID
+	}
 	planner.RedisClient.StreamsLogging(planner.RedisStreamName, eventData)
 }
 
 func (planner MyPlanner) ProcessPlanningEvent(worker int, wg *sync.WaitGroup) {
 	for event := range planner.PlanningEvents {
+		iowrappers.PlanningEventsQueueDepth.Set(float64(len(planner.PlanningEvents)))
 		log.Debugf("worker %d processing event for %s", worker, strings.Title(event.City)+", "+strings.ToUpper(event.Country))
 		planner.RedisClient.CollectPlanningAPIStats(event)
 	}
@@ -0,0 +1,173 @@
+package planner
+
+import (
+	"fmt"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"math"
+	"sort"
+	"time"
+)
+
+// scores are compared with an epsilon for floating-point errors
+const scoreEpsilon = 1e-9
+
+type ReplayResult struct {
+	Request      string    `json:"request"`
+	LatencyMs    float64   `json:"latency_ms"`
+	NumSolutions int       `json:"num_solutions"`
+	Scores       []float64 `json:"scores"` // in descending order
+	Error        string    `json:"error,omitempty"`
+}
+
+type Percentiles struct {
+	Min float64 `json:"min"`
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// the report of a replay is also the baseline of later replays of the same captured requests
+type ReplayReport struct {
+	NumRequests         int            `json:"num_requests"`
+	NumErrors           int            `json:"num_errors"`
+	NumWithoutSolutions int            `json:"num_without_solutions"`
+	LatencyMs           Percentiles    `json:"latency_ms"`
+	NumSolutions        Percentiles    `json:"num_solutions"`
+	TopScores           Percentiles    `json:"top_scores"` // score of the best solution of each request
+	Scores              Percentiles    `json:"scores"`     // scores of all solutions
+	Results             []ReplayResult `json:"results"`
+}
+
+// replay captured requests one by one, so that latencies are not affected by other requests
+// slot solutions are always computed by the solver instead of read from the cache
+func Replay(solver *solution.Solver, redisClient iowrappers.RedisClient, requests []CapturedRequest) (report ReplayReport) {
+	report.NumRequests = len(requests)
+	report.Results = make([]ReplayResult, len(requests))
+	latencies, numSolutions, topScores, scores := make([]float64, 0), make([]float64, 0), make([]float64, 0), make([]float64, 0)
+
+	for idx, captured := range requests {
+		result := ReplayResult{Request: captured.String(), Scores: make([]float64, 0)}
+		planningReq, err := captured.PlanningRequest()
+		if err == nil {
+			planningReq.SkipSlotSolutionCache = true
+			startTime := time.Now()
+			var resp solution.PlanningResponse
+			resp, err = solver.Solve(planningReq, redisClient)
+			result.LatencyMs = float64(time.Since(startTime).Microseconds()) / 1000
+			latencies = append(latencies, result.LatencyMs)
+			for _, multiSlotSolution := range resp.Solutions {
+				result.Scores = append(result.Scores, multiSlotSolution.Score)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.Float64Slice(result.Scores)))
+		result.NumSolutions = len(result.Scores)
+
+		if err != nil {
+			result.Error = err.Error()
+			report.NumErrors++
+		} else {
+			numSolutions = append(numSolutions, float64(result.NumSolutions))
+			if result.NumSolutions == 0 {
+				report.NumWithoutSolutions++
+			} else {
+				topScores = append(topScores, result.Scores[0])
+				scores = append(scores, result.Scores...)
+			}
+		}
+		report.Results[idx] = result
+	}
+
+	report.LatencyMs = percentiles(latencies)
+	report.NumSolutions = percentiles(numSolutions)
+	report.TopScores = percentiles(topScores)
+	report.Scores = percentiles(scores)
+	return
+}
+
+// nearest-rank percentiles, all zeros without values
+func percentiles(values []float64) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	rank := func(p float64) float64 {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return sorted[idx]
+	}
+	return Percentiles{Min: sorted[0], P50: rank(0.5), P90: rank(0.9), P99: rank(0.99), Max: sorted[len(sorted)-1]}
+}
+
+type ReplayRegression struct {
+	Request string `json:"request"`
+	Reason  string `json:"reason"`
+}
+
+// changes of a replay compared to the baseline, changes are the current values minus the baseline values
+type ReplayDiff struct {
+	LatencyMsChange Percentiles        `json:"latency_ms_change"`
+	TopScoresChange Percentiles        `json:"top_scores_change"`
+	NumImproved     int                `json:"num_improved"`
+	NumUnchanged    int                `json:"num_unchanged"`
+	Regressions     []ReplayRegression `json:"regressions"`
+}
+
+// compare each request with the same request in the baseline
+// a request regresses if it fails, finds fewer solutions or its best score drops by more than the score tolerance
+func DiffReplayReports(baseline ReplayReport, current ReplayReport, scoreTolerance float64) (diff ReplayDiff, err error) {
+	if len(baseline.Results) != len(current.Results) {
+		err = fmt.Errorf("baseline has %d requests, replay has %d requests", len(baseline.Results), len(current.Results))
+		return
+	}
+	diff.LatencyMsChange = current.LatencyMs.subtract(baseline.LatencyMs)
+	diff.TopScoresChange = current.TopScores.subtract(baseline.TopScores)
+	diff.Regressions = make([]ReplayRegression, 0)
+
+	for idx, result := range current.Results {
+		baselineResult := baseline.Results[idx]
+		if result.Request != baselineResult.Request {
+			err = fmt.Errorf("baseline is not recorded from the same captured requests, request %d is %s instead of %s",
+				idx+1, result.Request, baselineResult.Request)
+			return
+		}
+
+		regression := ReplayRegression{Request: result.Request}
+		improved := false
+		switch {
+		case result.Error != "" && baselineResult.Error == "":
+			regression.Reason = "error: " + result.Error
+		case result.Error != "":
+			// requests failing in both replays are unchanged
+		case baselineResult.Error != "":
+			improved = true
+		case result.NumSolutions < baselineResult.NumSolutions:
+			regression.Reason = fmt.Sprintf("%d solutions, baseline has %d solutions", result.NumSolutions, baselineResult.NumSolutions)
+		case baselineResult.NumSolutions > 0 && result.Scores[0] < baselineResult.Scores[0]-scoreTolerance-scoreEpsilon:
+			regression.Reason = fmt.Sprintf("top score %.4f, baseline top score %.4f", result.Scores[0], baselineResult.Scores[0])
+		case result.NumSolutions > baselineResult.NumSolutions:
+			improved = true
+		case baselineResult.NumSolutions > 0 && result.Scores[0] > baselineResult.Scores[0]+scoreTolerance+scoreEpsilon:
+			improved = true
+		}
+
+		switch {
+		case regression.Reason != "":
+			diff.Regressions = append(diff.Regressions, regression)
+		case improved:
+			diff.NumImproved++
+		default:
+			diff.NumUnchanged++
+		}
+	}
+	return
+}
+
+func (p Percentiles) subtract(other Percentiles) Percentiles {
+	return Percentiles{Min: p.Min - other.Min, P50: p.P50 - other.P50, P90: p.P90 - other.P90, P99: p.P99 - other.P99, Max: p.Max - other.Max}
+}
@@ -0,0 +1,188 @@
+package planner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/matching"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	CapturedRequestsFile   = "captured_requests.jsonl"
+	maxCapturedFieldLength = 100
+)
+
+// a planning request captured for replay, one JSON object per line
+// requests are captured after validation with the slots resolved from POST bodies and day templates
+// locations are sanitized, users, template names, API keys, IP addresses and headers are never captured
+type CapturedRequest struct {
+	Time         time.Time      `json:"time"`
+	Slots        []CapturedSlot `json:"slots"`
+	SearchRadius uint           `json:"search_radius"`
+	Weekday      POI.Weekday    `json:"weekday"`
+	Date         string         `json:"date,omitempty"`
+	NumResults   uint64         `json:"number_results"`
+}
+
+type CapturedSlot struct {
+	Location  string             `json:"location"` // city,country
+	EvOption  string             `json:"ev_option"`
+	StayTimes []POI.TimeInterval `json:"stay_times"`
+}
+
+func NewCapturedRequest(req *solution.PlanningRequest) CapturedRequest {
+	captured := CapturedRequest{
+		Slots:        make([]CapturedSlot, len(req.SlotRequests)),
+		SearchRadius: req.SearchRadius,
+		Weekday:      req.Weekday,
+		Date:         req.Date,
+		NumResults:   req.NumResults,
+	}
+	for idx, slotReq := range req.SlotRequests {
+		slot := CapturedSlot{Location: slotReq.Location, EvOption: slotReq.EvOption,
+			StayTimes: make([]POI.TimeInterval, len(slotReq.StayTimes))}
+		for timeIdx, stayTime := range slotReq.StayTimes {
+			slot.StayTimes[timeIdx] = stayTime.Slot
+		}
+		captured.Slots[idx] = slot
+	}
+	return captured
+}
+
+// append captured requests to a JSONL file shared by all handlers
+// methods of a nil recorder do nothing, so that capturing is disabled by default
+type RequestRecorder struct {
+	mutex   sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func NewRequestRecorder(path string) (*RequestRecorder, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &RequestRecorder{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+func (recorder *RequestRecorder) Record(captured CapturedRequest) {
+	if recorder == nil {
+		return
+	}
+	captured = captured.sanitize()
+	if captured.Time.IsZero() {
+		captured.Time = time.Now().UTC()
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	utils.CheckErrImmediate(recorder.encoder.Encode(captured), utils.LogError)
+}
+
+func (recorder *RequestRecorder) Close() error {
+	if recorder == nil {
+		return nil
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return recorder.file.Close()
+}
+
+// copy the request with locations trimmed and truncated
+func (captured CapturedRequest) sanitize() CapturedRequest {
+	slots := make([]CapturedSlot, len(captured.Slots))
+	for idx, slot := range captured.Slots {
+		parts := strings.Split(slot.Location, ",")
+		for partIdx, part := range parts {
+			parts[partIdx] = sanitizeCapturedField(part)
+		}
+		slot.Location = strings.Join(parts, ",")
+		slots[idx] = slot
+	}
+	captured.Slots = slots
+	return captured
+}
+
+func sanitizeCapturedField(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if runes := []rune(value); len(runes) > maxCapturedFieldLength {
+		value = string(runes[:maxCapturedFieldLength])
+	}
+	return value
+}
+
+// read captured requests, blank lines are ignored
+func ReadCapturedRequests(r io.Reader) (requests []CapturedRequest, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		captured := CapturedRequest{}
+		if err = json.Unmarshal([]byte(line), &captured); err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNum, err.Error())
+		}
+		requests = append(requests, captured)
+	}
+	err = scanner.Err()
+	return
+}
+
+// short description of the destinations and the day, used to match requests with the baseline
+func (captured CapturedRequest) String() string {
+	locations := make([]string, 0)
+	for _, slot := range captured.Slots {
+		if len(locations) == 0 || locations[len(locations)-1] != slot.Location {
+			locations = append(locations, slot.Location)
+		}
+	}
+	day := captured.Date
+	if day == "" {
+		// Monday is the first weekday of POI weekdays
+		day = time.Weekday((int(captured.Weekday) + 1) % 7).String()
+	}
+	description := []string{strings.Join(locations, "+"), day}
+	if len(captured.Slots) > 0 {
+		firstSlot, lastSlot := captured.Slots[0], captured.Slots[len(captured.Slots)-1]
+		if len(firstSlot.StayTimes) > 0 && len(lastSlot.StayTimes) > 0 {
+			description = append(description, firstSlot.StayTimes[0].Start.String()+"-"+
+				lastSlot.StayTimes[len(lastSlot.StayTimes)-1].End.String())
+		}
+	}
+	return strings.Join(description, " ")
+}
+
+// the solver request of a captured request
+func (captured CapturedRequest) PlanningRequest() (planningReq solution.PlanningRequest, err error) {
+	if len(captured.Slots) == 0 {
+		err = errors.New("captured request has no slots")
+		return
+	}
+	planningReq = solution.PlanningRequest{
+		SlotRequests: make([]solution.SlotRequest, len(captured.Slots)),
+		SearchRadius: captured.SearchRadius,
+		Weekday:      captured.Weekday,
+		Date:         captured.Date,
+		NumResults:   captured.NumResults,
+	}
+	for idx, slot := range captured.Slots {
+		slotReq := solution.SlotRequest{Location: slot.Location, EvOption: slot.EvOption,
+			StayTimes: make([]matching.TimeSlot, len(slot.StayTimes))}
+		for timeIdx, stayTime := range slot.StayTimes {
+			slotReq.StayTimes[timeIdx] = matching.TimeSlot{Slot: stayTime}
+		}
+		planningReq.SlotRequests[idx] = slotReq
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package planner
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/matching"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	MIMEGeoJSON = "application/geo+json"
+	MIMEGPX     = "application/gpx+xml"
+)
+
+// convert one of the plans in a planning response back to a multi-slot solution and its slot requests
+// so that persisted plans can be exported in the same way as newly solved plans
+func toMultiSlotSolution(resp PlanningResponse, planIdx int) (multiSlotSolution solution.MultiSlotSolution, slotRequests []solution.SlotRequest, err error) {
+	if planIdx < 0 || planIdx >= len(resp.Places) {
+		err = errors.New("plan option does not exist")
+		return
+	}
+
+	for _, timeSectionPlaces := range resp.Places[planIdx] {
+		candidate := solution.SlotSolutionCandidate{IsSet: true}
+		slotRequest := solution.SlotRequest{StayTimes: make([]matching.TimeSlot, 0)}
+		evTags := make([]string, 0)
+		for _, place := range timeSectionPlaces.Places {
+			candidate.PlaceNames = append(candidate.PlaceNames, place.PlaceName)
+			candidate.PlaceLocations = append(candidate.PlaceLocations, place.Location)
+			candidate.PlaceAddresses = append(candidate.PlaceAddresses, place.Address)
+			candidate.PlaceURLs = append(candidate.PlaceURLs, place.URL)
+			// plans saved before categories were recorded are treated as visits
+			if place.Category == POI.PlaceCategoryEatery {
+				evTags = append(evTags, "E")
+			} else {
+				evTags = append(evTags, "V")
+			}
+
+			timeSlot := matching.TimeSlot{}
+			timeSlot.Slot.Start = place.StartTime
+			timeSlot.Slot.End = place.EndTime
+			slotRequest.StayTimes = append(slotRequest.StayTimes, timeSlot)
+		}
+		slotRequest.EvOption = strings.Join(evTags, "")
+		multiSlotSolution.SlotSolutions = append(multiSlotSolution.SlotSolutions, candidate)
+		slotRequests = append(slotRequests, slotRequest)
+	}
+	return
+}
+
+// respond with the route of one of the plans in GeoJSON or GPX
+// the plan option is selected with the "option" query parameter, defaults to the top plan
+func renderRoute(c *gin.Context, format string, resp PlanningResponse) {
+	planIdx, err := strconv.Atoi(c.DefaultQuery("option", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "plan option must be an integer"})
+		return
+	}
+
+	multiSlotSolution, slotRequests, err := toMultiSlotSolution(resp, planIdx)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == ResponseFormatGeoJSON {
+		c.Header("Content-Type", MIMEGeoJSON)
+		c.JSON(http.StatusOK, solution.ToGeoJSON(multiSlotSolution, slotRequests))
+		return
+	}
+
+	gpx, err := solution.ToGPX(multiSlotSolution, slotRequests, "Vacation Plan for "+resp.TravelDestination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=\"vacation_plan.gpx\"")
+	c.Data(http.StatusOK, MIMEGPX, gpx)
+}
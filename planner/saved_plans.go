@@ -0,0 +1,192 @@
+package planner
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultPlansPageSize = 10
+	MaxPlansPageSize     = 100
+	GuestPlanExpiration  = 7 * 24 * time.Hour // plans of guests are shared by all guests and kept for a week
+)
+
+type PlanShareRequest struct {
+	ExpiresInHours uint `json:"expires_in_hours"` // optional, share tokens do not expire by default
+}
+
+type PlanShareResponse struct {
+	Token     string     `json:"token"`
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type SavedPlansResponse struct {
+	Plans    []iowrappers.PlanRecord `json:"plans"`
+	Page     int64                   `json:"page"`
+	PageSize int64                   `json:"page_size"`
+	Total    int64                   `json:"total"`
+}
+
+// load a persisted plan and check the current user is its owner
+// responds with not found or forbidden status if the plan cannot be accessed
+func (planner *MyPlanner) loadOwnedPlan(c *gin.Context, username string, planId string) (plan PlanningResponse, ok bool) {
+	record, err := planner.RedisClient.GetPlan(planId, &plan)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "plan does not exist"})
+		return
+	}
+	if record.Owner != username {
+		c.JSON(http.StatusForbidden, gin.H{"error": "operation forbidden, not the owner of the plan"})
+		return
+	}
+	plan.PlanID = record.ID
+	return plan, true
+}
+
+// HTTP GET API end-point for a persisted plan
+func (planner *MyPlanner) getSavedPlanApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	plan, ok := planner.loadOwnedPlan(c, username, c.Param("id"))
+	if !ok {
+		return
+	}
+	planner.renderPlanningResponse(c, responseFormat(c), plan)
+}
+
+// HTTP DELETE API end-point for a persisted plan
+func (planner *MyPlanner) deleteSavedPlanApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	planId := c.Param("id")
+	if _, ok := planner.loadOwnedPlan(c, username, planId); !ok {
+		return
+	}
+
+	if err := planner.RedisClient.DeletePlan(planId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "plan deleted"})
+}
+
+// HTTP GET API end-point for the plan history of the current user
+// plans are listed from the newest to the oldest, use page and page_size query parameters for pagination
+func (planner *MyPlanner) listSavedPlansApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	page, pageErr := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	if pageErr != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive integer"})
+		return
+	}
+
+	pageSize, pageSizeErr := strconv.ParseInt(c.DefaultQuery("page_size", strconv.Itoa(DefaultPlansPageSize)), 10, 64)
+	if pageSizeErr != nil || pageSize < 1 || pageSize > MaxPlansPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page size must be an integer between 1 and " + strconv.Itoa(MaxPlansPageSize)})
+		return
+	}
+
+	records, total, err := planner.RedisClient.ListUserPlans(username, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SavedPlansResponse{
+		Plans:    records,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// HTTP POST API end-point for sharing a persisted plan
+// the plan owner gets a read-only share token that does not require authentication
+func (planner *MyPlanner) createPlanShareApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	planId := c.Param("id")
+	if _, ok := planner.loadOwnedPlan(c, username, planId); !ok {
+		return
+	}
+
+	req := PlanShareRequest{}
+	// request body is optional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	expiration := time.Duration(req.ExpiresInHours) * time.Hour
+	token, err := planner.RedisClient.CreatePlanShareToken(planId, expiration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := PlanShareResponse{
+		Token: token,
+		URL:   "/v1/shared/" + token,
+	}
+	if expiration > 0 {
+		expiresAt := time.Now().Add(expiration)
+		resp.ExpiresAt = &expiresAt
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// HTTP DELETE API end-point for revoking a share token of a persisted plan
+func (planner *MyPlanner) revokePlanShareApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	planId := c.Param("id")
+	if _, ok := planner.loadOwnedPlan(c, username, planId); !ok {
+		return
+	}
+
+	if err := planner.RedisClient.RevokePlanShareToken(planId, c.Param("token")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "share token revoked"})
+}
+
+// HTTP GET API end-point for plans shared with a token
+// no authentication is required
+func (planner *MyPlanner) getSharedPlanApi(c *gin.Context) {
+	planId, err := planner.RedisClient.GetSharedPlanId(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shared plan does not exist or the link has expired"})
+		return
+	}
+
+	var plan PlanningResponse
+	if _, err = planner.RedisClient.GetPlan(planId, &plan); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shared plan does not exist or the link has expired"})
+		return
+	}
+	plan.PlanID = "" // the plan ID is only visible to the plan owner
+	planner.renderPlanningResponse(c, responseFormat(c), plan)
+}
@@ -0,0 +1,106 @@
+package planner
+
+import (
+	"fmt"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	TextFormatTable    = "table"
+	TextFormatMarkdown = "markdown"
+)
+
+// write rows as an aligned plain-text table or as a Markdown table
+func WriteTable(w io.Writer, format string, headers []string, rows [][]string) error {
+	if format == TextFormatMarkdown {
+		separators := make([]string, len(headers))
+		for idx := range separators {
+			separators[idx] = "---"
+		}
+		lines := []string{markdownRow(headers), markdownRow(separators)}
+		for _, row := range rows {
+			lines = append(lines, markdownRow(row))
+		}
+		_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+		return err
+	}
+
+	tableWriter := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	upperCaseHeaders := make([]string, len(headers))
+	for idx, header := range headers {
+		upperCaseHeaders[idx] = strings.ToUpper(header)
+	}
+	fmt.Fprintln(tableWriter, strings.Join(upperCaseHeaders, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tableWriter, strings.Join(row, "\t"))
+	}
+	return tableWriter.Flush()
+}
+
+// cells cannot contain pipes or line breaks
+func markdownRow(cells []string) string {
+	escapedCells := make([]string, len(cells))
+	for idx, cell := range cells {
+		cell = strings.ReplaceAll(cell, "|", "\\|")
+		escapedCells[idx] = strings.Join(strings.Fields(cell), " ")
+	}
+	return "| " + strings.Join(escapedCells, " | ") + " |"
+}
+
+// write the itinerary of each plan as a plain-text or Markdown table
+func WritePlanningText(w io.Writer, format string, resp PlanningResponse) (err error) {
+	title := resp.TravelDestination + ", " + planningDay(resp)
+	headingPrefix := ""
+	if format == TextFormatMarkdown {
+		title = "# " + title
+		headingPrefix = "## "
+	}
+	if _, err = fmt.Fprintf(w, "%s\n\n", title); err != nil {
+		return
+	}
+
+	for planIdx, timeSectionPlaces := range resp.Places {
+		if _, err = fmt.Fprintf(w, "%sPlan %d\n\n", headingPrefix, planIdx+1); err != nil {
+			return
+		}
+		rows := make([][]string, 0)
+		for _, section := range timeSectionPlaces {
+			for _, place := range section.Places {
+				name := place.PlaceName
+				if format == TextFormatMarkdown && place.URL != "" {
+					name = "[" + name + "](" + place.URL + ")"
+				}
+				rows = append(rows, []string{place.StartTime.String(), place.EndTime.String(), string(place.Category), name, place.Address})
+			}
+		}
+		if err = WriteTable(w, format, []string{"Start", "End", "Category", "Place", "Address"}, rows); err != nil {
+			return
+		}
+		if _, err = fmt.Fprintln(w); err != nil {
+			return
+		}
+	}
+
+	for _, travelLeg := range resp.TravelLegs {
+		if _, err = fmt.Fprintf(w, "Travel from %s to %s takes about %d minutes\n", travelLeg.From, travelLeg.To, travelLeg.TravelTimeInMin); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// the date and the weekday of a plan, or only the weekday for plans without a date
+func planningDay(resp PlanningResponse) string {
+	weekday := time.Weekday((int(resp.Weekday) + 1) % 7).String()
+	if resp.Date == "" {
+		return weekday
+	}
+	if date, err := POI.ParseDate(resp.Date, time.UTC); err == nil {
+		weekday = date.Weekday().String()
+	}
+	return resp.Date + " (" + weekday + ")"
+}
@@ -0,0 +1,160 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"net/http"
+	"time"
+)
+
+const (
+	MaxTripDays     = 14
+	MaxSyncTripDays = 3 // longer trips are planned by the planning job workers to avoid server timeouts
+	TripDateLayout  = POI.DateLayout
+)
+
+// multi-day trip request
+// each day of the trip is planned with the same daily template
+type TripPlanningRequest struct {
+	StartDate     string              `json:"start_date"` // e.g. "2020-10-01"
+	NumDays       uint                `json:"num_days"`
+	DailyTemplate PlanningPostRequest `json:"daily_template"`
+}
+
+type TripDayPlan struct {
+	Date    string              `json:"date"`
+	Weekday POI.Weekday         `json:"weekday"`
+	Places  []TimeSectionPlaces `json:"time_section_places"`
+	Score   float64             `json:"score"`
+}
+
+type TripPlanningResponse struct {
+	TravelDestination string        `json:"travel_destination"`
+	Days              []TripDayPlan `json:"days"`
+	Score             float64       `json:"score"`
+	Err               string        `json:"error"`
+	StatusCode        uint          `json:"status_code"`
+}
+
+// the daily template is validated with the first day
+// the start date is checked in the time zone of the destination in the same way as single-day plans
+func (planner *MyPlanner) validateTripPlanningRequest(req *TripPlanningRequest) (startDate time.Time, err error) {
+	startDate, err = time.Parse(TripDateLayout, req.StartDate)
+	if err != nil {
+		err = fmt.Errorf("invalid start date %s, expected format is YYYY-MM-DD", req.StartDate)
+		return
+	}
+	if req.NumDays == 0 || req.NumDays > MaxTripDays {
+		err = fmt.Errorf("number of days must be between 1 and %d", MaxTripDays)
+		return
+	}
+
+	firstDayReq := req.DailyTemplate
+	firstDayReq.Date = startDate.Format(TripDateLayout)
+	planningReq, err := ProcessPlanningPostRequest(&firstDayReq, planner.Config)
+	if err != nil || len(planningReq.SlotRequests) == 0 {
+		return
+	}
+	timeZone, timeZoneErr := planner.Solver.GetTimeZone(planningReq.SlotRequests[0].Location)
+	if utils.CheckErrImmediate(timeZoneErr, utils.LogError) {
+		return
+	}
+	err = ValidatePlanningDate(req.StartDate, timeZone, time.Now())
+	return
+}
+
+// multi-day, single-city planning method
+// solve each day of the trip in order and never visit the same place twice during the trip
+func (planner *MyPlanner) TripPlanning(req *TripPlanningRequest, requester Requester) (resp TripPlanningResponse) {
+	startDate, err := planner.validateTripPlanningRequest(req)
+	if err != nil {
+		resp.Err = err.Error()
+		resp.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	visitedPlaceIDs := make([]string, 0)
+	for day := 0; day < int(req.NumDays); day++ {
+		date := startDate.AddDate(0, 0, day)
+		dailyReq := req.DailyTemplate
+		dailyReq.Date = date.Format(TripDateLayout)
+
+		planningReq, reqErr := planner.processPlanningPostRequest(&dailyReq)
+		if reqErr != nil {
+			resp.Err = reqErr.Error()
+			resp.StatusCode = http.StatusBadRequest
+			return
+		}
+		planningReq.NumResults = 1
+		planningReq.ExcludedPlaceIDs = visitedPlaceIDs
+
+		planningResp, solveErr := planner.Solver.Solve(planningReq, planner.RedisClient)
+		utils.CheckErrImmediate(solveErr, utils.LogError)
+		if solveErr != nil {
+			resp.Err = solveErr.Error()
+			resp.StatusCode = planningResp.Errcode
+			return
+		}
+
+		if len(planningResp.Solutions) == 0 {
+			resp.Err = errors.New("cannot find a valid solution for " + date.Format(TripDateLayout)).Error()
+			resp.StatusCode = solution.NoValidSolution
+			return
+		}
+
+		if day == 0 {
+			planner.logPlanningEvent(&planningReq, requester)
+			resp.TravelDestination = travelDestination(&planningReq)
+		}
+
+		bestSolution := planningResp.Solutions[0]
+		for _, slotSolution := range bestSolution.SlotSolutions {
+			visitedPlaceIDs = append(visitedPlaceIDs, slotSolution.PlaceIDS...)
+		}
+
+		resp.Days = append(resp.Days, TripDayPlan{
+			Date:    date.Format(TripDateLayout),
+			Weekday: planningReq.Weekday,
+			Places:  toTimeSectionPlaces(&planningReq, bestSolution),
+			Score:   bestSolution.Score,
+		})
+		resp.Score += bestSolution.Score
+	}
+
+	resp.StatusCode = solution.ValidSolutionFound
+	return
+}
+
+// HTTP POST API end-point for multi-day trips
+func (planner *MyPlanner) postTripPlanningApi(c *gin.Context) {
+	username, authenticated := planner.authenticate(c)
+	if !authenticated {
+		return
+	}
+
+	req := TripPlanningRequest{}
+	err := c.ShouldBindJSON(&req)
+	utils.CheckErrImmediate(err, utils.LogInfo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status_code": http.StatusBadRequest})
+		return
+	}
+
+	// trips are validated before they are queued
+	if _, err = planner.validateTripPlanningRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "status_code": http.StatusBadRequest})
+		return
+	}
+
+	if req.NumDays > MaxSyncTripDays {
+		planner.queuePlanningJob(c, username, PlanningJob{Requester: planner.requester(c, username), Trip: &req})
+		return
+	}
+
+	tripResp := planner.TripPlanning(&req, planner.requester(c, username))
+	c.JSON(int(tripResp.StatusCode), tripResp)
+}
@@ -3,19 +3,39 @@ package planner
 import (
 	"encoding/json"
 	"errors"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
 	"github.com/weihesdlegend/Vacation-planner/user"
+	"github.com/weihesdlegend/Vacation-planner/utils"
 	"net/http"
 	"os"
 	"strings"
 )
 
+const (
+	APIKeyHeader       = "X-API-Key"
+	AccessTokenCookie  = "JWT"
+	RefreshTokenCookie = "JWT_REFRESH"
+	apiKeyIdContextKey = "api_key_id"
+	GuestUsername      = "guest" // identity of requests without login outside of production
+)
+
+// the user making a request, and the API key used if the user is authenticated with an API key
+type Requester struct {
+	Username string
+	APIKeyID string
+}
+
 type UserLoginResponse struct {
 	Username string `json:"username"`
-	Jwt      string `json:"jwt"`
+	Jwt      string `json:"jwt"` // the access token
 	Status   string `json:"status"`
+	user.TokenPair
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 // user signup POST request handler
@@ -31,23 +51,36 @@ func (planner MyPlanner) UserSignup(c *gin.Context) {
 	}
 
 	userLevel := user.LevelRegular
-	adminUsers := strings.Split(os.Getenv("ADMIN_USERS"), ",")
-	for _, username := range adminUsers {
-		if username == u.Username {
-			userLevel = user.LevelAdmin
-		}
+	if isConfiguredAdmin(u.Username) {
+		userLevel = user.LevelAdmin
 	}
 
 	u.UserLevel = userLevel
+	u.EmailVerified = false
+	u.Disabled = false
 
 	createErr := planner.RedisClient.CreateUser(u)
 	if createErr != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": createErr.Error()})
 		return
 	}
+	if u.Email != "" {
+		utils.CheckErrImmediate(planner.sendVerificationEmail(u.Username, u.Email), utils.LogError)
+	}
 	_ = json.NewEncoder(c.Writer).Encode("user created")
 }
 
+// users in the ADMIN_USERS environment variable are admins when they sign up
+// and cannot be removed, disabled or demoted
+func isConfiguredAdmin(username string) bool {
+	for _, adminUser := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+		if adminUser != "" && adminUser == username {
+			return true
+		}
+	}
+	return false
+}
+
 // user login POST request handler
 // user submit credentials and return JWT if login is successful
 func (planner MyPlanner) UserLogin(ctx *gin.Context) {
@@ -59,7 +92,7 @@ func (planner MyPlanner) UserLogin(ctx *gin.Context) {
 		return
 	}
 
-	token, tokenExpirationTime, loginErr := planner.RedisClient.Authenticate(c)
+	tokens, loginErr := planner.RedisClient.Authenticate(c)
 	if loginErr != nil {
 		log.Debug(loginErr)
 		ctx.JSON(http.StatusUnauthorized, UserLoginResponse{
@@ -70,45 +103,206 @@ func (planner MyPlanner) UserLogin(ctx *gin.Context) {
 		return
 	}
 
-	http.SetCookie(ctx.Writer, &http.Cookie{
-		Name:    "JWT",
-		Value:   token,
-		Expires: tokenExpirationTime,
+	setTokenCookies(ctx, tokens)
+	ctx.JSON(http.StatusOK, UserLoginResponse{
+		Username:  c.Username,
+		Jwt:       tokens.AccessToken,
+		Status:    "you are logged in",
+		TokenPair: tokens,
 	})
+}
 
-	http.SetCookie(ctx.Writer, &http.Cookie{
-		Name:  "Username",
-		Value: c.Username,
-	})
+// token refresh POST request handler
+// a refresh token can be used only once, the user gets a new access token and a new refresh token
+func (planner MyPlanner) refreshTokenApi(ctx *gin.Context) {
+	refreshToken := refreshTokenOfRequest(ctx)
+	if refreshToken == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "refresh token is required"})
+		return
+	}
 
+	claims, err := planner.RedisClient.ValidateToken(refreshToken, user.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	// users removed or disabled after the token is issued cannot refresh tokens
+	if err = planner.RedisClient.CheckUserActive(claims.Username); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err = planner.RedisClient.RevokeToken(claims); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := iowrappers.IssueTokenPair(claims.Username, claims.Generation)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setTokenCookies(ctx, tokens)
 	ctx.JSON(http.StatusOK, UserLoginResponse{
-		Username: c.Username,
-		Jwt:      token,
-		Status:   "you are logged in",
+		Username:  claims.Username,
+		Jwt:       tokens.AccessToken,
+		Status:    "token refreshed",
+		TokenPair: tokens,
 	})
 }
 
-func (planner MyPlanner) UserAuthentication(r *http.Request) (username string, err error) {
-	cookie, cookieErr := r.Cookie("JWT")
-	if cookieErr != nil {
-		return "", cookieErr
+// user logout POST request handler
+// the access token and the refresh token of the request are revoked until they expire
+func (planner MyPlanner) UserLogout(ctx *gin.Context) {
+	var revokedClaims []*user.Claims
+	if accessToken := accessTokenOfRequest(ctx.Request); accessToken != "" {
+		if claims, err := planner.RedisClient.ValidateToken(accessToken, user.AccessToken); err == nil {
+			revokedClaims = append(revokedClaims, claims)
+		}
+	}
+	if refreshToken := refreshTokenOfRequest(ctx); refreshToken != "" {
+		if claims, err := planner.RedisClient.ValidateToken(refreshToken, user.RefreshToken); err == nil {
+			revokedClaims = append(revokedClaims, claims)
+		}
+	}
+	if len(revokedClaims) == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user is not logged in"})
+		return
+	}
+	// both tokens must belong to the same user
+	for _, claims := range revokedClaims {
+		if claims.Username != revokedClaims[0].Username {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "tokens belong to different users"})
+			return
+		}
 	}
 
-	jwtKey := []byte(os.Getenv("JWT_SIGNING_SECRET"))
-	token, tokenErr := jwt.Parse(cookie.Value, func(tkn *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
+	for _, claims := range revokedClaims {
+		if err := planner.RedisClient.RevokeToken(claims); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	clearTokenCookies(ctx)
+	ctx.JSON(http.StatusOK, gin.H{"status": "you are logged out"})
+}
+
+func setTokenCookies(ctx *gin.Context, tokens user.TokenPair) {
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     AccessTokenCookie,
+		Value:    tokens.AccessToken,
+		Path:     "/",
+		Expires:  tokens.AccessTokenExpiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
 	})
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     RefreshTokenCookie,
+		Value:    tokens.RefreshToken,
+		Path:     "/v1",
+		Expires:  tokens.RefreshTokenExpiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearTokenCookies(ctx *gin.Context) {
+	http.SetCookie(ctx.Writer, &http.Cookie{Name: AccessTokenCookie, Path: "/", MaxAge: -1})
+	http.SetCookie(ctx.Writer, &http.Cookie{Name: RefreshTokenCookie, Path: "/v1", MaxAge: -1})
+}
+
+// returns the username of the current user, guest users are allowed in non-production environments
+// responds with unauthorized status if the user cannot be authenticated
+func (planner *MyPlanner) authenticate(c *gin.Context) (username string, ok bool) {
+	username, apiKeyId, err := planner.identify(c.Request)
+	// invalid API keys are rejected in all environments
+	if err != nil && planner.Environment != "production" && apiKeyOfRequest(c.Request) == "" {
+		return GuestUsername, true // default username
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return "", false
+	}
+	if apiKeyId != "" {
+		c.Set(apiKeyIdContextKey, apiKeyId)
+	}
+	return username, true
+}
+
+// the requester of an authenticated request
+func (planner *MyPlanner) requester(c *gin.Context, username string) Requester {
+	return Requester{Username: username, APIKeyID: c.GetString(apiKeyIdContextKey)}
+}
+
+// find the API key in the X-API-Key header, or in the Authorization header with the Bearer or ApiKey scheme
+func apiKeyOfRequest(r *http.Request) string {
+	if apiKey := strings.TrimSpace(r.Header.Get(APIKeyHeader)); apiKey != "" {
+		return apiKey
+	}
+	authorization := strings.Fields(r.Header.Get("Authorization"))
+	if len(authorization) == 2 && (strings.EqualFold(authorization[0], "Bearer") || strings.EqualFold(authorization[0], "ApiKey")) &&
+		iowrappers.IsAPIKey(authorization[1]) {
+		return authorization[1]
+	}
+	return ""
+}
+
+// find the access token in the Authorization header with the Bearer scheme, or in the JWT cookie
+func accessTokenOfRequest(r *http.Request) string {
+	authorization := strings.Fields(r.Header.Get("Authorization"))
+	if len(authorization) == 2 && strings.EqualFold(authorization[0], "Bearer") && !iowrappers.IsAPIKey(authorization[1]) {
+		return authorization[1]
+	}
+	if cookie, err := r.Cookie(AccessTokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// find the refresh token in the request body or in the refresh token cookie
+func refreshTokenOfRequest(ctx *gin.Context) string {
+	req := RefreshTokenRequest{}
+	if err := ctx.ShouldBindJSON(&req); err == nil && req.RefreshToken != "" {
+		return req.RefreshToken
+	}
+	if cookie, err := ctx.Request.Cookie(RefreshTokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
 
-	if tokenErr != nil {
-		return "", tokenErr
+// identify the user with an API key if one is provided, otherwise with the access token
+func (planner MyPlanner) identify(r *http.Request) (username string, apiKeyId string, err error) {
+	if apiKey := apiKeyOfRequest(r); apiKey != "" {
+		username, apiKeyId, err = planner.RedisClient.AuthenticateAPIKey(apiKey)
+	} else {
+		username, err = planner.tokenAuthentication(r)
+	}
+	if err != nil {
+		return "", "", err
 	}
+	// credentials of disabled or deleted users are rejected
+	if err = planner.RedisClient.CheckUserActive(username); err != nil {
+		return "", "", err
+	}
+	return
+}
 
-	if !token.Valid {
-		return "", errors.New("invalid token")
+func (planner MyPlanner) UserAuthentication(r *http.Request) (username string, err error) {
+	username, _, err = planner.identify(r)
+	return
+}
+
+// the username is taken from the claims of a valid access token only
+func (planner MyPlanner) tokenAuthentication(r *http.Request) (username string, err error) {
+	accessToken := accessTokenOfRequest(r)
+	if accessToken == "" {
+		return "", errors.New("user is not logged in")
 	}
 
-	userCookie, _ := r.Cookie("Username")
-	username = userCookie.Value
-	log.Debugf("the current user is %s", username)
-	return username, nil
+	claims, err := planner.RedisClient.ValidateToken(accessToken, user.AccessToken)
+	if err != nil {
+		return "", err
+	}
+	log.Debugf("the current user is %s", claims.Username)
+	return claims.Username, nil
 }
@@ -0,0 +1,178 @@
+package planner
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weihesdlegend/Vacation-planner/user"
+	"github.com/weihesdlegend/Vacation-planner/utils"
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultUsersPageSize = 20
+	MaxUsersPageSize     = 100
+)
+
+// user information visible to admins
+type UserResponse struct {
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	UserLevel     string `json:"user_level"`
+	EmailVerified bool   `json:"email_verified"`
+	Disabled      bool   `json:"disabled"`
+	Protected     bool   `json:"protected"` // users in ADMIN_USERS cannot be removed, disabled or demoted
+}
+
+type UsersResponse struct {
+	Users    []UserResponse `json:"users"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Total    int            `json:"total"`
+}
+
+type UserLevelRequest struct {
+	UserLevel string `json:"user_level"`
+}
+
+func toUserResponse(u user.User) UserResponse {
+	return UserResponse{
+		Username:      u.Username,
+		Email:         u.Email,
+		UserLevel:     u.UserLevel,
+		EmailVerified: u.EmailVerified,
+		Disabled:      u.Disabled,
+		Protected:     isConfiguredAdmin(u.Username),
+	}
+}
+
+// admins cannot change their own accounts or protected accounts
+// so that there is always an admin who can manage users
+func checkUserChangeAllowed(c *gin.Context, adminUsername string, username string) bool {
+	if username == adminUsername {
+		c.JSON(http.StatusForbidden, gin.H{"error": "operation forbidden, admins cannot change their own accounts"})
+		return false
+	}
+	if isConfiguredAdmin(username) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "operation forbidden, cannot change admin user " + username})
+		return false
+	}
+	return true
+}
+
+// HTTP GET API end-point for all the users ordered by username
+func (planner *MyPlanner) listUsersApi(c *gin.Context) {
+	if _, ok := planner.authenticateAdmin(c); !ok {
+		return
+	}
+
+	page, pageErr := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if pageErr != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive integer"})
+		return
+	}
+	pageSize, pageSizeErr := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(DefaultUsersPageSize)))
+	if pageSizeErr != nil || pageSize < 1 || pageSize > MaxUsersPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page size must be an integer between 1 and " + strconv.Itoa(MaxUsersPageSize)})
+		return
+	}
+
+	users, err := planner.RedisClient.ListUsers()
+	if utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users"})
+		return
+	}
+
+	resp := UsersResponse{Users: make([]UserResponse, 0), Page: page, PageSize: pageSize, Total: len(users)}
+	for idx := (page - 1) * pageSize; idx < len(users) && idx < page*pageSize; idx++ {
+		resp.Users = append(resp.Users, toUserResponse(users[idx]))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// HTTP GET API end-point for an user
+func (planner *MyPlanner) getUserApi(c *gin.Context) {
+	if _, ok := planner.authenticateAdmin(c); !ok {
+		return
+	}
+
+	u, err := planner.RedisClient.FindUser(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toUserResponse(u))
+}
+
+// HTTP PUT API end-point for promoting or demoting an user
+func (planner *MyPlanner) setUserLevelApi(c *gin.Context) {
+	adminUsername, ok := planner.authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	req := UserLevelRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.UserLevel != user.LevelAdmin && req.UserLevel != user.LevelRegular {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user level must be " + user.LevelAdmin + " or " + user.LevelRegular})
+		return
+	}
+
+	username := c.Param("username")
+	if !checkUserChangeAllowed(c, adminUsername, username) {
+		return
+	}
+	if err := planner.RedisClient.SetUserLevel(username, req.UserLevel); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "user level updated"})
+}
+
+// HTTP POST API end-points for disabling and enabling an user
+func (planner *MyPlanner) setUserDisabledApi(disabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminUsername, ok := planner.authenticateAdmin(c)
+		if !ok {
+			return
+		}
+
+		username := c.Param("username")
+		if !checkUserChangeAllowed(c, adminUsername, username) {
+			return
+		}
+		if err := planner.RedisClient.SetUserDisabled(username, disabled); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if disabled {
+			c.JSON(http.StatusOK, gin.H{"status": "user disabled"})
+		} else {
+			c.JSON(http.StatusOK, gin.H{"status": "user enabled"})
+		}
+	}
+}
+
+// HTTP DELETE API end-point for removing an user and the data of the user
+func (planner *MyPlanner) deleteUserApi(c *gin.Context) {
+	adminUsername, ok := planner.authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	username := c.Param("username")
+	if !checkUserChangeAllowed(c, adminUsername, username) {
+		return
+	}
+	if _, err := planner.RedisClient.FindUser(username); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err := planner.RedisClient.DeleteUser(username); utils.CheckErrImmediate(err, utils.LogError) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "user deleted"})
+}
@@ -0,0 +1,184 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	WarmUpConcurrency  = 4
+	WarmUpSearchRadius = 10000 // meters, the default radius of planning GET requests
+)
+
+type WarmUpDestination struct {
+	Country string
+	City    string
+}
+
+func (destination WarmUpDestination) String() string {
+	return destination.City + "," + destination.Country
+}
+
+// destinations of CSV rows in the format of "country,city" as in data/capitals.csv
+// blank and duplicate rows are ignored
+func ParseWarmUpDestinations(rows [][]string) (destinations []WarmUpDestination, err error) {
+	seen := make(map[string]bool)
+	for rowIdx, row := range rows {
+		if len(row) == 0 || (len(row) == 1 && strings.TrimSpace(row[0]) == "") {
+			continue
+		}
+		if len(row) != 2 || strings.TrimSpace(row[0]) == "" || strings.TrimSpace(row[1]) == "" {
+			return nil, fmt.Errorf("row %d: expected country and city", rowIdx+1)
+		}
+		destination := WarmUpDestination{Country: strings.TrimSpace(row[0]), City: strings.TrimSpace(row[1])}
+		if seen[destination.String()] {
+			continue
+		}
+		seen[destination.String()] = true
+		destinations = append(destinations, destination)
+	}
+	return
+}
+
+// pre-populates the caches for the first users planning in a destination
+// destinations completed in a previous run are skipped until the cached slot solutions expire
+type CacheWarmer struct {
+	RedisClient *iowrappers.RedisClient
+	PoiSearcher *iowrappers.PoiSearcher
+	// solvers are not safe for concurrent use, each worker solves with its own solver of the config
+	SolverConfig solution.SolverConfig
+	Concurrency  int
+	SearchRadius uint
+	Weekdays     []POI.Weekday // slot solutions are cached for each weekday
+}
+
+type WarmUpFailure struct {
+	Destination string `json:"destination"`
+	Error       string `json:"error"`
+}
+
+type WarmUpSummary struct {
+	Total     int             `json:"total"`
+	Completed int             `json:"completed"`
+	Skipped   int             `json:"skipped"`
+	Failed    int             `json:"failed"`
+	Failures  []WarmUpFailure `json:"failures"`
+	Seconds   float64         `json:"seconds"`
+}
+
+func (warmer *CacheWarmer) setDefaults() {
+	if warmer.Concurrency <= 0 {
+		warmer.Concurrency = WarmUpConcurrency
+	}
+	if warmer.SearchRadius == 0 {
+		warmer.SearchRadius = WarmUpSearchRadius
+	}
+	if warmer.SolverConfig == (solution.SolverConfig{}) {
+		warmer.SolverConfig = solution.DefaultSolverConfig()
+	}
+	if len(warmer.Weekdays) == 0 {
+		for weekday := POI.DateMonday; weekday <= POI.DateSunday; weekday++ {
+			warmer.Weekdays = append(warmer.Weekdays, weekday)
+		}
+	}
+}
+
+// warm up destinations with Concurrency workers
+// onDone is optional and called after each destination with a nil error for completed or skipped destinations
+func (warmer *CacheWarmer) Run(destinations []WarmUpDestination, onDone func(destination WarmUpDestination, skipped bool, err error)) WarmUpSummary {
+	warmer.setDefaults()
+	startTime := time.Now()
+	summary := WarmUpSummary{Total: len(destinations), Failures: make([]WarmUpFailure, 0)}
+	summaryMutex := &sync.Mutex{}
+
+	destinationsToWarmUp := make(chan WarmUpDestination)
+	wg := &sync.WaitGroup{}
+	for workerIdx := 0; workerIdx < warmer.Concurrency; workerIdx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var solver *solution.Solver
+			for destination := range destinationsToWarmUp {
+				skipped, err := warmer.RedisClient.IsWarmUpCompleted(destination.String())
+				if err == nil && !skipped {
+					// created for the first destination not completed yet, so that completed runs do not need Maps APIs
+					if solver == nil {
+						solver = &solution.Solver{}
+						solver.Init(warmer.PoiSearcher, warmer.SolverConfig)
+					}
+					err = warmer.warmUpDestination(solver, destination)
+				}
+
+				summaryMutex.Lock()
+				switch {
+				case err != nil:
+					summary.Failed++
+					summary.Failures = append(summary.Failures, WarmUpFailure{Destination: destination.String(), Error: err.Error()})
+				case skipped:
+					summary.Skipped++
+				default:
+					summary.Completed++
+				}
+				if onDone != nil {
+					onDone(destination, skipped, err)
+				}
+				summaryMutex.Unlock()
+			}
+		}()
+	}
+	for _, destination := range destinations {
+		destinationsToWarmUp <- destination
+	}
+	close(destinationsToWarmUp)
+	wg.Wait()
+
+	summary.Seconds = time.Since(startTime).Seconds()
+	return summary
+}
+
+// geocode, nearby places of both categories and slot solutions of the standard template for each weekday
+func (warmer *CacheWarmer) warmUpDestination(solver *solution.Solver, destination WarmUpDestination) (err error) {
+	query := &iowrappers.GeocodeQuery{City: destination.City, Country: destination.Country}
+	if _, _, err = warmer.PoiSearcher.GetGeocode(query); err != nil {
+		return
+	}
+	// the geocoding service may correct the destination
+	location := query.City + "," + query.Country
+
+	for _, placeCat := range []POI.PlaceCategory{POI.PlaceCategoryVisit, POI.PlaceCategoryEatery} {
+		// same search as the time matcher of the solver, so that the solver finds the cached places
+		request := &iowrappers.PlaceSearchRequest{
+			Location:      location,
+			PlaceCat:      placeCat,
+			Radius:        warmer.SearchRadius,
+			RankBy:        "prominence",
+			MinNumResults: warmer.SolverConfig.TimeClusterMinResults,
+			MaxNumResults: 2 * warmer.SolverConfig.TimeClusterMinResults,
+		}
+		if _, err = warmer.PoiSearcher.NearbySearch(request); err != nil {
+			return
+		}
+	}
+
+	template, _ := solution.SystemDayTemplate(solution.StandardTemplateName)
+	for _, weekday := range warmer.Weekdays {
+		req := template.PlanningRequest(location, weekday, solution.NumSolutions)
+		req.SearchRadius = warmer.SearchRadius
+		resp, solveErr := solver.Solve(req, *warmer.RedisClient)
+		if solveErr != nil {
+			return solveErr
+		}
+		if len(resp.Solutions) == 0 {
+			return errors.New("cannot find a valid solution on weekday " + time.Weekday((int(weekday)+1)%7).String())
+		}
+	}
+
+	err = warmer.RedisClient.SetWarmUpCompleted(destination.String())
+	return
+}
@@ -107,7 +107,7 @@ func (slotSolution *SlotSolution) IsCandidateTagValid(slotCandidate SlotSolution
 	return true
 }
 
-func (slotSolution *SlotSolution) CreateCandidate(iter MDtagIter, categorizedPlaces []CategorizedPlaces) (res SlotSolutionCandidate) {
+func (slotSolution *SlotSolution) CreateCandidate(iter MDtagIter, categorizedPlaces []CategorizedPlaces, priceLevels matching.PriceLevels) (res SlotSolutionCandidate) {
 	if len(iter.Status) != len(slotSolution.SlotTag) {
 		return
 	}
@@ -143,7 +143,7 @@ func (slotSolution *SlotSolution) CreateCandidate(iter MDtagIter, categorizedPla
 		}
 		res.PlaceURLs = append(res.PlaceURLs, place.GetURL())
 	}
-	res.Score = matching.Score(places)
+	res.Score = matching.Score(places, priceLevels)
 	res.IsSet = true
 	return
 }
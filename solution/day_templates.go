@@ -0,0 +1,156 @@
+package solution
+
+import (
+	"errors"
+	"fmt"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/matching"
+	"regexp"
+	"strings"
+)
+
+const (
+	StandardTemplateName       = "standard"
+	RelaxedTemplateName        = "relaxed"
+	FoodieTemplateName         = "foodie"
+	MuseumMarathonTemplateName = "museum marathon"
+	MaxTemplateSlots           = 6
+	MaxTemplatePlaces          = 12
+)
+
+var templateNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9 _-]{0,39}$`)
+
+// the shape of a slot in a day template, places of the slot are visited in the order of the EV option
+type SlotTemplate struct {
+	EvOption  string             `json:"ev_option"`  // e.g. "EVV", "VEV"
+	StayTimes []POI.TimeInterval `json:"stay_times"` // one for each place in the EV option
+}
+
+// an ordered list of slots describing a day
+type DayTemplate struct {
+	Name   string         `json:"name"`
+	Slots  []SlotTemplate `json:"slots"`
+	System bool           `json:"system"` // built-in templates are shared by all users and cannot be changed
+}
+
+func interval(startHour, startMinute, endHour, endMinute int) POI.TimeInterval {
+	return POI.TimeInterval{Start: POI.NewTimeOfDay(startHour, startMinute), End: POI.NewTimeOfDay(endHour, endMinute)}
+}
+
+// built-in templates in the order of listing
+var systemDayTemplates = []DayTemplate{
+	{
+		Name: StandardTemplateName,
+		Slots: []SlotTemplate{
+			{EvOption: "EV", StayTimes: []POI.TimeInterval{interval(9, 0, 10, 0), interval(10, 0, 12, 0)}},
+			{EvOption: "EV", StayTimes: []POI.TimeInterval{interval(12, 0, 13, 0), interval(13, 0, 17, 0)}},
+			{EvOption: "E", StayTimes: []POI.TimeInterval{interval(18, 0, 20, 0)}},
+		},
+		System: true,
+	},
+	{
+		Name: RelaxedTemplateName,
+		Slots: []SlotTemplate{
+			{EvOption: "EV", StayTimes: []POI.TimeInterval{interval(10, 0, 11, 30), interval(11, 30, 14, 0)}},
+			{EvOption: "EV", StayTimes: []POI.TimeInterval{interval(14, 0, 15, 30), interval(15, 30, 18, 0)}},
+			{EvOption: "E", StayTimes: []POI.TimeInterval{interval(19, 0, 21, 0)}},
+		},
+		System: true,
+	},
+	{
+		Name: FoodieTemplateName,
+		Slots: []SlotTemplate{
+			{EvOption: "EVE", StayTimes: []POI.TimeInterval{interval(8, 30, 9, 30), interval(9, 30, 11, 30), interval(11, 30, 13, 0)}},
+			{EvOption: "VE", StayTimes: []POI.TimeInterval{interval(13, 0, 15, 0), interval(15, 0, 16, 0)}},
+			{EvOption: "VE", StayTimes: []POI.TimeInterval{interval(16, 30, 18, 30), interval(18, 30, 20, 30)}},
+		},
+		System: true,
+	},
+	{
+		Name: MuseumMarathonTemplateName,
+		Slots: []SlotTemplate{
+			{EvOption: "VV", StayTimes: []POI.TimeInterval{interval(9, 30, 11, 30), interval(11, 30, 13, 0)}},
+			{EvOption: "EVV", StayTimes: []POI.TimeInterval{interval(13, 0, 14, 0), interval(14, 0, 16, 0), interval(16, 0, 18, 0)}},
+			{EvOption: "E", StayTimes: []POI.TimeInterval{interval(18, 30, 20, 0)}},
+		},
+		System: true,
+	},
+}
+
+// normalize template names to lower case without surrounding spaces
+func NormalizeTemplateName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func SystemDayTemplates() []DayTemplate {
+	templates := make([]DayTemplate, len(systemDayTemplates))
+	copy(templates, systemDayTemplates)
+	return templates
+}
+
+func SystemDayTemplate(name string) (template DayTemplate, exists bool) {
+	name = NormalizeTemplateName(name)
+	for _, systemTemplate := range systemDayTemplates {
+		if systemTemplate.Name == name {
+			return systemTemplate, true
+		}
+	}
+	return
+}
+
+// validate a user-defined template
+// slots are visited in order and stay times of places cannot overlap
+func (template *DayTemplate) Validate() error {
+	if !templateNameRegexp.MatchString(template.Name) {
+		return errors.New("template name must have 1-40 lower case letters, digits, spaces, hyphens or underscores")
+	}
+	if _, exists := SystemDayTemplate(template.Name); exists {
+		return fmt.Errorf("template name %s is reserved", template.Name)
+	}
+	if len(template.Slots) == 0 || len(template.Slots) > MaxTemplateSlots {
+		return fmt.Errorf("number of slots must be between 1 and %d", MaxTemplateSlots)
+	}
+
+	numPlaces := 0
+	var lastEnd POI.TimeOfDay
+	for slotIdx, slot := range template.Slots {
+		if !isSlotTagValid(slot.EvOption) {
+			return fmt.Errorf("slot %d: EV option must have 1-%d of E or V", slotIdx+1, LimitPerSlot)
+		}
+		if len(slot.StayTimes) != len(slot.EvOption) {
+			return fmt.Errorf("slot %d: %s", slotIdx+1, ReqTimeSlotsTagMismatchErrMsg)
+		}
+		for _, stayTime := range slot.StayTimes {
+			if stayTime.Start >= stayTime.End || stayTime.End > POI.EndOfDay {
+				return fmt.Errorf("slot %d: invalid stay time %s-%s", slotIdx+1, stayTime.Start, stayTime.End)
+			}
+			if stayTime.Start < lastEnd {
+				return fmt.Errorf("slot %d: stay time %s-%s starts before the previous place ends", slotIdx+1, stayTime.Start, stayTime.End)
+			}
+			lastEnd = stayTime.End
+		}
+		numPlaces += len(slot.EvOption)
+	}
+	if numPlaces > MaxTemplatePlaces {
+		return fmt.Errorf("total number of places cannot exceed %d", MaxTemplatePlaces)
+	}
+	return nil
+}
+
+// generate a planning request with all slots of the template in the same location
+func (template *DayTemplate) PlanningRequest(location string, weekday POI.Weekday, numResults uint64) (req PlanningRequest) {
+	for _, slot := range template.Slots {
+		slotRequest := SlotRequest{
+			Location:  location,
+			EvOption:  strings.ToUpper(slot.EvOption),
+			StayTimes: make([]matching.TimeSlot, len(slot.StayTimes)),
+		}
+		for idx, stayTime := range slot.StayTimes {
+			slotRequest.StayTimes[idx] = matching.TimeSlot{Slot: stayTime}
+		}
+		req.SlotRequests = append(req.SlotRequests, slotRequest)
+	}
+	req.Weekday = weekday
+	req.NumResults = numResults
+	return
+}
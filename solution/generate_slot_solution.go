@@ -12,7 +12,7 @@ import (
 )
 
 const (
-	CandidateQueueLength                  = 15
+	CandidateQueueLength                  = 15 // default number of candidates kept for each slot
 	ReqTimeSlotsTagMismatchErrMsg         = "user designated stay times list length does not match tag length"
 	CategorizedPlaceIterInitFailureErrMsg = "categorized places iterator init failure"
 )
@@ -26,7 +26,8 @@ type TripEvent struct {
 }
 
 // Find top solution candidates
-func FindBestCandidates(candidates []SlotSolutionCandidate) []SlotSolutionCandidate {
+// at most queueLength candidates are kept
+func FindBestCandidates(candidates []SlotSolutionCandidate, queueLength int) []SlotSolutionCandidate {
 	m := make(map[string]SlotSolutionCandidate) // map for result extraction
 	vertexes := make([]graph.Vertex, len(candidates))
 	for idx, candidate := range candidates {
@@ -38,7 +39,7 @@ func FindBestCandidates(candidates []SlotSolutionCandidate) []SlotSolutionCandid
 	// use limited-size minimum priority queue
 	priorityQueue := &graph.MinPriorityQueueVertex{}
 	for _, vertex := range vertexes {
-		if priorityQueue.Len() == CandidateQueueLength {
+		if priorityQueue.Len() == queueLength {
 			top := (*priorityQueue)[0]
 			if vertex.Key > top.Key {
 				heap.Pop(priorityQueue)
@@ -61,8 +62,9 @@ func FindBestCandidates(candidates []SlotSolutionCandidate) []SlotSolutionCandid
 
 // Generate slot solution candidates
 // Parameter list matches slot request
+// results are cached by the solver
 func GenerateSlotSolution(timeMatcher *matching.TimeMatcher, location string, evTag string, stayTimes []matching.TimeSlot,
-	radius uint, weekday POI.Weekday, redisClient iowrappers.RedisClient, redisReq iowrappers.SlotSolutionCacheRequest) (slotSolution SlotSolution, slotSolutionRedisKey string, err error) {
+	radius uint, weekday POI.Weekday, onPlaceSearch matching.PlaceSearchCallback, candidateQueueLength int) (slotSolution SlotSolution, slotSolutionRedisKey string, err error) {
 	if len(stayTimes) != len(evTag) {
 		err = errors.New(ReqTimeSlotsTagMismatchErrMsg)
 		return
@@ -88,6 +90,14 @@ func GenerateSlotSolution(timeMatcher *matching.TimeMatcher, location string, ev
 
 	req.Weekday = weekday
 
+	req.OnPlaceSearch = onPlaceSearch
+
+	// matching time excludes the time of nearby search
+	matchingStartTime := time.Now()
+	defer func() {
+		iowrappers.ObserveSolverStage(iowrappers.SolverStageMatching, time.Since(matchingStartTime)-req.SearchDuration)
+	}()
+
 	placeClusters := timeMatcher.Matching(&req)
 
 	categorizedPlaces := make([]CategorizedPlaces, len(placeClusters))
@@ -107,7 +117,7 @@ func GenerateSlotSolution(timeMatcher *matching.TimeMatcher, location string, ev
 	}
 
 	for mdIter.HasNext() {
-		curCandidate := slotSolution.CreateCandidate(mdIter, categorizedPlaces)
+		curCandidate := slotSolution.CreateCandidate(mdIter, categorizedPlaces, timeMatcher.PriceLevels)
 
 		if curCandidate.IsSet {
 			_, travelTimeInMin := GetTravelTimeByDistance(categorizedPlaces, mdIter)
@@ -117,10 +127,13 @@ func GenerateSlotSolution(timeMatcher *matching.TimeMatcher, location string, ev
 		}
 		mdIter.Next()
 	}
-	bestCandidates := FindBestCandidates(slotCandidates)
+	bestCandidates := FindBestCandidates(slotCandidates, candidateQueueLength)
 	slotSolution.SlotSolutionCandidates = append(slotSolution.SlotSolutionCandidates, bestCandidates...)
+	return
+}
 
-	// cache slot solution calculation results
+// cache slot solution calculation results
+func cacheSlotSolution(redisClient iowrappers.RedisClient, redisReq iowrappers.SlotSolutionCacheRequest, slotSolution SlotSolution) {
 	slotSolutionToCache := iowrappers.SlotSolutionCacheResponse{}
 	slotSolutionToCache.SlotSolutionCandidate = make([]iowrappers.SlotSolutionCandidateCache, len(slotSolution.SlotSolutionCandidates))
 
@@ -137,6 +150,4 @@ func GenerateSlotSolution(timeMatcher *matching.TimeMatcher, location string, ev
 	}
 
 	redisClient.CacheSlotSolution(redisReq, slotSolutionToCache)
-
-	return
 }
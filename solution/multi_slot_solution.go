@@ -2,7 +2,10 @@ package solution
 
 import (
 	"container/heap"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/weihesdlegend/Vacation-planner/POI"
 	"github.com/weihesdlegend/Vacation-planner/graph"
 	"github.com/weihesdlegend/Vacation-planner/iowrappers"
@@ -10,6 +13,7 @@ import (
 	"github.com/weihesdlegend/Vacation-planner/utils"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -18,9 +22,30 @@ const (
 	TimeLimitBetweenClusters = 60 // minutes
 )
 
+var errSlotSolutionCacheSkipped = errors.New("slot solution cache is skipped")
+
 // Solvers are used by planners to solve the planning problem
 type Solver struct {
 	matcher *matching.TimeMatcher
+	config  SolverConfig
+}
+
+type SolverConfig struct {
+	TravelSpeed              float64 // km/h
+	TimeLimitBetweenClusters uint    // minutes, maximum travel time between slots
+	CandidateQueueLength     int     // number of candidates kept for each slot
+	TimeClusterMinResults    uint    // minimum number of places of each category found by nearby search
+	PriceLevels              matching.PriceLevels
+}
+
+func DefaultSolverConfig() SolverConfig {
+	return SolverConfig{
+		TravelSpeed:              TravelSpeed,
+		TimeLimitBetweenClusters: TimeLimitBetweenClusters,
+		CandidateQueueLength:     CandidateQueueLength,
+		TimeClusterMinResults:    graph.TimeClusterMinResults,
+		PriceLevels:              matching.DefaultPriceLevels(),
+	}
 }
 
 // mapping from status to standard http status codes
@@ -34,23 +59,55 @@ const (
 	NoValidSolution              = 404
 )
 
-func (solver *Solver) Init(poiSearcher *iowrappers.PoiSearcher) {
+// short hash of the config, configs with the same values have the same fingerprint
+func (config SolverConfig) Fingerprint() string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%+v", config)))
+	return hex.EncodeToString(hash[:8])
+}
+
+func (solver *Solver) Init(poiSearcher *iowrappers.PoiSearcher, config SolverConfig) {
+	solver.config = config
 	solver.matcher = &matching.TimeMatcher{}
-	solver.matcher.Init(poiSearcher)
+	solver.matcher.Init(poiSearcher, config.TimeClusterMinResults, config.PriceLevels)
 }
 
 func (solver *Solver) ValidateLocation(slotRequestLocation *string) bool {
+	_, err := solver.geocodeLocation(slotRequestLocation)
+	return err == nil
+}
+
+// geocode a location of "city,country" and correct the location name
+// returns the location in the format of "lat,lng"
+func (solver *Solver) geocodeLocation(slotRequestLocation *string) (latLng string, err error) {
 	countryCity := strings.Split(*slotRequestLocation, ",")
+	if len(countryCity) != 2 {
+		err = errors.New("location must be in the format of city,country")
+		return
+	}
 	geoQuery := iowrappers.GeocodeQuery{
 		City:    countryCity[0],
 		Country: countryCity[1],
 	}
-	_, _, err := solver.matcher.PoiSearcher.GetGeocode(&geoQuery)
+	lat, lng, err := solver.matcher.PoiSearcher.GetGeocode(&geoQuery)
 	if err != nil {
-		return false
+		return
 	}
 	*slotRequestLocation = strings.Join([]string{geoQuery.City, geoQuery.Country}, ",")
-	return true
+	latLng = fmt.Sprintf("%f,%f", lat, lng)
+	return
+}
+
+// find the time zone of a location of "city,country"
+func (solver *Solver) GetTimeZone(location string) (timeZoneId string, err error) {
+	countryCity := strings.Split(location, ",")
+	if len(countryCity) != 2 {
+		err = errors.New("location must be in the format of city,country")
+		return
+	}
+	return solver.matcher.PoiSearcher.GetTimeZone(&iowrappers.GeocodeQuery{
+		City:    countryCity[0],
+		Country: countryCity[1],
+	})
 }
 
 func GenerateSlotSolutionRedisRequest(location string, evTag string, stayTimes []matching.TimeSlot, radius uint, weekday POI.Weekday) iowrappers.SlotSolutionCacheRequest {
@@ -77,20 +134,35 @@ func GenerateSlotSolutionRedisRequest(location string, evTag string, stayTimes [
 }
 
 func (solver *Solver) Solve(req PlanningRequest, redisCli iowrappers.RedisClient) (resp PlanningResponse, err error) {
-	if !travelTimeValidation(req) {
-		err = errors.New("travel time limit exceeded for current selection")
-		resp.Errcode = InvalidSolverReqTimeInterval
-		return
-	}
+	return solver.SolveWithProgress(req, redisCli, nil)
+}
 
+// solve a planning request and report progress after each step
+// progress reporter is optional
+func (solver *Solver) SolveWithProgress(req PlanningRequest, redisCli iowrappers.RedisClient, progress ProgressReporter) (resp PlanningResponse, err error) {
 	// validate location with poiSearcher of the time matcher
+	// each slot is geocoded separately since slots may be in different cities
+	geocodes := make([]string, len(req.SlotRequests))
+	geocodeStartTime := time.Now()
 	for idx := range req.SlotRequests {
-		if !solver.ValidateLocation(&req.SlotRequests[idx].Location) {
+		geocode, geocodeErr := solver.geocodeLocation(&req.SlotRequests[idx].Location)
+		if geocodeErr != nil {
 			err = errors.New("invalid travel destination")
 			resp.Errcode = InvalidRequestLocation
 			return
 		}
+		geocodes[idx] = geocode
+		progress.report(ProgressEvent{Type: ProgressGeocode, Slot: idx, Location: req.SlotRequests[idx].Location, Geocode: geocode})
 	}
+	iowrappers.ObserveSolverStage(iowrappers.SolverStageGeocode, time.Since(geocodeStartTime))
+
+	travelLegs, travelTimeValid := solver.travelTimeValidation(req, geocodes)
+	if !travelTimeValid {
+		err = errors.New("travel time limit exceeded for current selection")
+		resp.Errcode = InvalidSolverReqTimeInterval
+		return
+	}
+	resp.TravelLegs = travelLegs
 
 	// set default number of planning results
 	if req.NumResults == 0 {
@@ -104,12 +176,22 @@ func (solver *Solver) Solve(req PlanningRequest, redisCli iowrappers.RedisClient
 	}
 
 	redisRequests := make([]iowrappers.SlotSolutionCacheRequest, len(req.SlotRequests))
+	configFingerprint := solver.config.Fingerprint()
 	for idx, slotRequest := range req.SlotRequests {
 		location, evTag, stayTimes := slotRequest.Location, slotRequest.EvOption, slotRequest.StayTimes
 		redisRequests[idx] = GenerateSlotSolutionRedisRequest(location, evTag, stayTimes, req.SearchRadius, req.Weekday)
+		redisRequests[idx].ConfigFingerprint = configFingerprint
 	}
 
-	slotSolutionCacheResponses := redisCli.GetMultiSlotSolutions(redisRequests)
+	var slotSolutionCacheResponses []iowrappers.SlotSolutionCacheResponse
+	if req.SkipSlotSolutionCache {
+		slotSolutionCacheResponses = make([]iowrappers.SlotSolutionCacheResponse, len(redisRequests))
+		for idx := range slotSolutionCacheResponses {
+			slotSolutionCacheResponses[idx].Err = errSlotSolutionCacheSkipped
+		}
+	} else {
+		slotSolutionCacheResponses = redisCli.GetMultiSlotSolutions(redisRequests)
+	}
 
 	slotSolutionRedisKeys := make([]string, len(req.SlotRequests))
 	for idx, slotRequest := range req.SlotRequests {
@@ -130,10 +212,15 @@ func (solver *Solver) Solve(req PlanningRequest, redisCli iowrappers.RedisClient
 				slotSolution.SlotSolutionCandidates = append(slotSolution.SlotSolutionCandidates, slotSolutionCandidate)
 			}
 			candidates[idx] = append(candidates[idx], slotSolution.SlotSolutionCandidates...)
+			progress.report(ProgressEvent{Type: ProgressSlotCandidates, Slot: idx, Location: slotRequest.Location, FromCache: true, Candidates: candidates[idx]})
 			continue
 		}
 		location, evTag, stayTimes := slotRequest.Location, slotRequest.EvOption, slotRequest.StayTimes
-		slotSolution, slotSolutionRedisKey, err := GenerateSlotSolution(solver.matcher, location, evTag, stayTimes, req.SearchRadius, req.Weekday, redisCli, redisRequests[idx])
+		slotIdx := idx
+		onPlaceSearch := func(placeCat POI.PlaceCategory, numPlaces int) {
+			progress.report(ProgressEvent{Type: ProgressNearbySearch, Slot: slotIdx, Location: location, Category: placeCat, NumPlaces: numPlaces})
+		}
+		slotSolution, slotSolutionRedisKey, err := GenerateSlotSolution(solver.matcher, location, evTag, stayTimes, req.SearchRadius, req.Weekday, onPlaceSearch, solver.config.CandidateQueueLength)
 		// The candidates in each slot should satisfy the travel time constraints and inter-slot constraint
 		if err != nil {
 			if err.Error() == ReqTimeSlotsTagMismatchErrMsg {
@@ -145,12 +232,20 @@ func (solver *Solver) Solve(req PlanningRequest, redisCli iowrappers.RedisClient
 			}
 			return resp, err
 		}
+		if !req.SkipSlotSolutionCache {
+			cacheSlotSolution(redisCli, redisRequests[idx], slotSolution)
+		}
 		candidates[idx] = append(candidates[idx], slotSolution.SlotSolutionCandidates...)
 		slotSolutionRedisKeys[idx] = slotSolutionRedisKey
+		progress.report(ProgressEvent{Type: ProgressSlotCandidates, Slot: idx, Location: location, Candidates: candidates[idx]})
 	}
 
-	resp.Solutions = genBestMultiSlotSolutions(candidates, req.NumResults)
-	if len(resp.Solutions) == 0 {
+	rankingStartTime := time.Now()
+	resp.Solutions = solver.genBestMultiSlotSolutions(candidates, req.NumResults, req.ExcludedPlaceIDs)
+	iowrappers.ObserveSolverStage(iowrappers.SolverStageDFSRanking, time.Since(rankingStartTime))
+	progress.report(ProgressEvent{Type: ProgressSolutions, NumSolutions: len(resp.Solutions)})
+	// exclusion of places may cause no valid solution even if the cached slot solutions are valid
+	if len(resp.Solutions) == 0 && len(req.ExcludedPlaceIDs) == 0 {
 		invalidateSlotSolutionCache(&redisCli, slotSolutionRedisKeys)
 	}
 	return
@@ -162,33 +257,51 @@ func invalidateSlotSolutionCache(redisCli *iowrappers.RedisClient, slotSolutionR
 
 // return false if travel time between clusters exceed limit
 // use upper-bound of the sum of radius plus distance between cluster centers
-func travelTimeValidation(req PlanningRequest) bool {
+// geocodes are the slot request locations in the format of "lat,lng"
+// returns the travel legs between slots in different cities
+func (solver *Solver) travelTimeValidation(req PlanningRequest, geocodes []string) (travelLegs []TravelLeg, valid bool) {
 	numTimeSlots := len(req.SlotRequests)
+	travelLegs = make([]TravelLeg, 0)
 
 	for i := 0; i < numTimeSlots-1; i++ {
 		prevRequest := req.SlotRequests[i]
 		nextRequest := req.SlotRequests[i+1]
-		if travelTime(prevRequest.Location, nextRequest.Location, req.SearchRadius, req.SearchRadius) > TimeLimitBetweenClusters {
-			return false
+		travelTimeInMin := solver.travelTime(geocodes[i], geocodes[i+1], req.SearchRadius, req.SearchRadius)
+		if travelTimeInMin > solver.config.TimeLimitBetweenClusters {
+			return
+		}
+		if prevRequest.Location != nextRequest.Location {
+			travelLegs = append(travelLegs, TravelLeg{
+				From:            prevRequest.Location,
+				To:              nextRequest.Location,
+				FromSlot:        i,
+				ToSlot:          i + 1,
+				TravelTimeInMin: travelTimeInMin,
+			})
 		}
 	}
-	return true
+	valid = true
+	return
 }
 
-func travelTime(fromLoc string, toLoc string, fromLocRadius uint, toLocRadius uint) uint {
+func (solver *Solver) travelTime(fromLoc string, toLoc string, fromLocRadius uint, toLocRadius uint) uint {
 	latLng1, _ := utils.ParseLocation(fromLoc)
 	latLng2, _ := utils.ParseLocation(toLoc)
 
 	distance := utils.HaversineDist(latLng1, latLng2) + float64(fromLocRadius+toLocRadius)
 
-	return uint(distance / (TravelSpeed * 16.67)) // 16.67 is the ratio of m/minute and km/hour
+	return uint(distance / (solver.config.TravelSpeed * 16.67)) // 16.67 is the ratio of m/minute and km/hour
 }
 
-func genBestMultiSlotSolutions(candidates [][]SlotSolutionCandidate, numResults uint64) []MultiSlotSolution {
+func (solver *Solver) genBestMultiSlotSolutions(candidates [][]SlotSolutionCandidate, numResults uint64, excludedPlaceIDs []string) []MultiSlotSolution {
 	res := make([]MultiSlotSolution, 0)
 	slotSolutionResults := make([][]SlotSolutionCandidate, 0)
 	path := make([]SlotSolutionCandidate, 0)
 	placeMap := make(map[string]bool)
+	// excluded places are treated as if they were already visited
+	for _, placeId := range excludedPlaceIDs {
+		placeMap[placeId] = true
+	}
 	dfs(candidates, 0, path, &slotSolutionResults, placeMap)
 
 	// after dfs, slot solution results are in the shape of number of multi-slot results by number of slots
@@ -204,12 +317,12 @@ func genBestMultiSlotSolutions(candidates [][]SlotSolutionCandidate, numResults
 	}
 	bestSolutions := FindBestSolutions(res, numResults)
 	for solutionIdx := range bestSolutions {
-		calTravelTime(&bestSolutions[solutionIdx])
+		solver.calTravelTime(&bestSolutions[solutionIdx])
 	}
 	return bestSolutions
 }
 
-func calTravelTime(solution *MultiSlotSolution) {
+func (solver *Solver) calTravelTime(solution *MultiSlotSolution) {
 	numTimeSlots := len(solution.SlotSolutions)
 
 	for slotIdx := 0; slotIdx < numTimeSlots-1; slotIdx++ {
@@ -221,7 +334,7 @@ func calTravelTime(solution *MultiSlotSolution) {
 		endLatLng[0], endLatLng[1] = endPlace[0], endPlace[1]
 
 		distance := utils.HaversineDist(startLatLng, endLatLng)
-		intervalTime := uint(distance / (TravelSpeed * 16.67))
+		intervalTime := uint(distance / (solver.config.TravelSpeed * 16.67))
 
 		solution.TravelTimes = append(solution.TravelTimes, intervalTime)
 		solution.TotalTime += intervalTime
@@ -285,10 +398,14 @@ type MultiSlotSolution struct {
 }
 
 type PlanningRequest struct {
-	SlotRequests []SlotRequest
-	SearchRadius uint
-	Weekday      POI.Weekday
-	NumResults   uint64
+	SlotRequests     []SlotRequest
+	SearchRadius     uint
+	Weekday          POI.Weekday
+	Date             string // YYYY-MM-DD at the destination, empty if only the weekday is known
+	NumResults       uint64
+	ExcludedPlaceIDs []string // places that cannot appear in the solutions, e.g. places visited on other days
+	// slot solutions are computed without reading or writing the cache, e.g. to replay requests after a scoring change
+	SkipSlotSolutionCache bool
 }
 
 type SlotRequest struct {
@@ -297,13 +414,24 @@ type SlotRequest struct {
 	StayTimes []matching.TimeSlot // e.g. ["8AM-10AM", "10AM-11AM", "11AM-12PM"]
 }
 
+// travel between two consecutive slots in different cities
+type TravelLeg struct {
+	From            string `json:"from"` // city,country
+	To              string `json:"to"`   // city,country
+	FromSlot        int    `json:"from_slot"`
+	ToSlot          int    `json:"to_slot"`
+	TravelTimeInMin uint   `json:"travel_time_in_min"`
+}
+
 type PlanningResponse struct {
-	Solutions []MultiSlotSolution
-	Err       error
-	Errcode   uint
+	Solutions  []MultiSlotSolution
+	TravelLegs []TravelLeg
+	Err        error
+	Errcode    uint
 }
 
 // Find top multi-slot solutions
+// solutions are sorted by score in descending order
 func FindBestSolutions(candidates []MultiSlotSolution, numResults uint64) []MultiSlotSolution {
 	res := make([]MultiSlotSolution, 0)
 
@@ -333,43 +461,11 @@ func FindBestSolutions(candidates []MultiSlotSolution, numResults uint64) []Mult
 		heap.Push(priorityQueue, vertex)
 	}
 
-	for priorityQueue.Len() > 0 {
+	res = make([]MultiSlotSolution, priorityQueue.Len())
+	for idx := len(res) - 1; idx >= 0; idx-- {
 		top := heap.Pop(priorityQueue).(graph.Vertex)
-		res = append(res, m[top.Name])
+		res[idx] = m[top.Name]
 	}
 
 	return res
 }
-
-// Generate a standard request while we seek a better way to represent complex REST requests
-func GetStandardRequest(weekday POI.Weekday, numResults uint64) (req PlanningRequest) {
-	slot12 := matching.TimeSlot{Slot: POI.TimeInterval{Start: 9, End: 10}}
-	slot13 := matching.TimeSlot{Slot: POI.TimeInterval{Start: 10, End: 12}}
-	stayTimes1 := []matching.TimeSlot{slot12, slot13}
-	slotReq1 := SlotRequest{
-		Location:  "",
-		EvOption:  "EV",
-		StayTimes: stayTimes1,
-	}
-	slot21 := matching.TimeSlot{Slot: POI.TimeInterval{Start: 12, End: 13}}
-	slot22 := matching.TimeSlot{Slot: POI.TimeInterval{Start: 13, End: 17}}
-	stayTimes2 := []matching.TimeSlot{slot21, slot22}
-	slotReq2 := SlotRequest{
-		Location:  "",
-		EvOption:  "EV",
-		StayTimes: stayTimes2,
-	}
-
-	slot31 := matching.TimeSlot{Slot: POI.TimeInterval{Start: 18, End: 20}}
-	stayTimes3 := []matching.TimeSlot{slot31}
-	slotReq3 := SlotRequest{
-		Location:  "",
-		EvOption:  "E",
-		StayTimes: stayTimes3,
-	}
-
-	req.SlotRequests = append(req.SlotRequests, []SlotRequest{slotReq1, slotReq2, slotReq3}...)
-	req.Weekday = weekday
-	req.NumResults = numResults
-	return
-}
@@ -0,0 +1,35 @@
+package solution
+
+import "github.com/weihesdlegend/Vacation-planner/POI"
+
+// types of progress events emitted while solving a planning request
+const (
+	ProgressGeocode        = "geocode"
+	ProgressNearbySearch   = "nearby_search"
+	ProgressSlotCandidates = "slot_candidates"
+	ProgressSolutions      = "solutions"
+)
+
+// a step of the solving process
+// fields not related to the type of the event are left empty
+type ProgressEvent struct {
+	Type         string                  `json:"type"`
+	Slot         int                     `json:"slot"`
+	Location     string                  `json:"location,omitempty"` // city,country
+	Geocode      string                  `json:"geocode,omitempty"`  // latitude,longitude
+	Category     POI.PlaceCategory       `json:"category,omitempty"`
+	NumPlaces    int                     `json:"num_places,omitempty"`
+	FromCache    bool                    `json:"from_cache,omitempty"`
+	Candidates   []SlotSolutionCandidate `json:"-"`
+	NumSolutions int                     `json:"num_solutions,omitempty"`
+}
+
+// receives progress events in the order of solving steps
+// called from the goroutine solving the request
+type ProgressReporter func(event ProgressEvent)
+
+func (reporter ProgressReporter) report(event ProgressEvent) {
+	if reporter != nil {
+		reporter(event)
+	}
+}
@@ -0,0 +1,181 @@
+package solution
+
+import (
+	"encoding/xml"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"strings"
+)
+
+const (
+	GPXVersion = "1.1"
+	GPXCreator = "Unwind Vacation Planner"
+	GPXSchema  = "http://www.topografix.com/GPX/1/1"
+)
+
+// GeoJSON objects per RFC 7946
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type GeoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// GPX 1.1 document with waypoints and a route
+type GPX struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Namespace string        `xml:"xmlns,attr"`
+	Metadata  GPXMetadata   `xml:"metadata"`
+	Waypoints []GPXWaypoint `xml:"wpt"`
+	Route     GPXRoute      `xml:"rte"`
+}
+
+type GPXMetadata struct {
+	Name string `xml:"name"`
+}
+
+type GPXWaypoint struct {
+	Lat         float64  `xml:"lat,attr"`
+	Lon         float64  `xml:"lon,attr"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"desc,omitempty"`
+	Link        *GPXLink `xml:"link,omitempty"`
+	Type        string   `xml:"type,omitempty"`
+}
+
+type GPXLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type GPXRoute struct {
+	Name   string        `xml:"name"`
+	Points []GPXWaypoint `xml:"rtept"`
+}
+
+// a stop of a multi-slot solution in the visiting order
+type routeStop struct {
+	name      string
+	address   string
+	url       string
+	category  POI.PlaceCategory
+	location  [2]float64 // longitude, latitude
+	startTime POI.TimeOfDay
+	endTime   POI.TimeOfDay
+}
+
+// flatten a multi-slot solution into stops in the visiting order
+// slot requests provide place categories and visiting times of the places
+func routeStops(multiSlotSolution MultiSlotSolution, slotRequests []SlotRequest) []routeStop {
+	stops := make([]routeStop, 0)
+	for slotIdx, slotSolution := range multiSlotSolution.SlotSolutions {
+		for placeIdx, placeName := range slotSolution.PlaceNames {
+			stop := routeStop{name: placeName}
+			if placeIdx < len(slotSolution.PlaceLocations) {
+				stop.location = slotSolution.PlaceLocations[placeIdx]
+			}
+			if placeIdx < len(slotSolution.PlaceAddresses) {
+				stop.address = slotSolution.PlaceAddresses[placeIdx]
+			}
+			if placeIdx < len(slotSolution.PlaceURLs) {
+				stop.url = slotSolution.PlaceURLs[placeIdx]
+			}
+			if slotIdx < len(slotRequests) {
+				slotRequest := slotRequests[slotIdx]
+				if placeIdx < len(slotRequest.EvOption) {
+					stop.category = placeCategoryOfTag(slotRequest.EvOption[placeIdx])
+				}
+				if placeIdx < len(slotRequest.StayTimes) {
+					stop.startTime = slotRequest.StayTimes[placeIdx].Slot.Start
+					stop.endTime = slotRequest.StayTimes[placeIdx].Slot.End
+				}
+			}
+			stops = append(stops, stop)
+		}
+	}
+	return stops
+}
+
+func placeCategoryOfTag(tag byte) POI.PlaceCategory {
+	if tag == 'e' || tag == 'E' {
+		return POI.PlaceCategoryEatery
+	}
+	return POI.PlaceCategoryVisit
+}
+
+// ToGeoJSON converts a multi-slot solution to a GeoJSON feature collection
+// each place is a Point feature, and a LineString feature connects the places in the visiting order
+func ToGeoJSON(multiSlotSolution MultiSlotSolution, slotRequests []SlotRequest) GeoJSONFeatureCollection {
+	collection := GeoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]GeoJSONFeature, 0)}
+
+	stops := routeStops(multiSlotSolution, slotRequests)
+	lineCoordinates := make([][2]float64, 0)
+	for idx, stop := range stops {
+		collection.Features = append(collection.Features, GeoJSONFeature{
+			Type:     "Feature",
+			Geometry: GeoJSONGeometry{Type: "Point", Coordinates: stop.location},
+			Properties: map[string]interface{}{
+				"name":       stop.name,
+				"category":   stop.category,
+				"start_time": stop.startTime.String(),
+				"end_time":   stop.endTime.String(),
+				"address":    stop.address,
+				"url":        stop.url,
+				"order":      idx + 1,
+			},
+		})
+		lineCoordinates = append(lineCoordinates, stop.location)
+	}
+
+	// a valid LineString requires at least two positions
+	if len(lineCoordinates) >= 2 {
+		collection.Features = append(collection.Features, GeoJSONFeature{
+			Type:       "Feature",
+			Geometry:   GeoJSONGeometry{Type: "LineString", Coordinates: lineCoordinates},
+			Properties: map[string]interface{}{"name": "visiting order"},
+		})
+	}
+	return collection
+}
+
+// ToGPX converts a multi-slot solution to a GPX document with waypoints and a route in the visiting order
+func ToGPX(multiSlotSolution MultiSlotSolution, slotRequests []SlotRequest, name string) ([]byte, error) {
+	gpx := GPX{
+		Version:   GPXVersion,
+		Creator:   GPXCreator,
+		Namespace: GPXSchema,
+		Metadata:  GPXMetadata{Name: name},
+		Waypoints: make([]GPXWaypoint, 0),
+		Route:     GPXRoute{Name: name, Points: make([]GPXWaypoint, 0)},
+	}
+
+	for _, stop := range routeStops(multiSlotSolution, slotRequests) {
+		waypoint := GPXWaypoint{
+			Lat:         stop.location[1],
+			Lon:         stop.location[0],
+			Name:        stop.name,
+			Description: strings.TrimSpace(stop.startTime.String() + "-" + stop.endTime.String() + " " + stop.address),
+			Type:        string(stop.category),
+		}
+		if stop.url != "" {
+			waypoint.Link = &GPXLink{Href: stop.url}
+		}
+		gpx.Waypoints = append(gpx.Waypoints, waypoint)
+		gpx.Route.Points = append(gpx.Route.Points, GPXWaypoint{Lat: waypoint.Lat, Lon: waypoint.Lon, Name: waypoint.Name})
+	}
+
+	data, err := xml.MarshalIndent(gpx, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
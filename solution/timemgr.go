@@ -13,7 +13,7 @@ func GetTimeSlotLengthInMin(placeClusters []matching.PlaceCluster) int {
 	}
 	var start = placeClusters[0].Slot.Slot.Start
 	var end = placeClusters[len(placeClusters)-1].Slot.Slot.End
-	var min = int((end - start) * 60)
+	var min = int(end) - int(start)
 	return utils.MaxInt(0, min)
 }
 
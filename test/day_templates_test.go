@@ -0,0 +1,64 @@
+package test
+
+import (
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"testing"
+)
+
+func TestSystemDayTemplates(t *testing.T) {
+	for _, name := range []string{solution.StandardTemplateName, solution.RelaxedTemplateName, solution.FoodieTemplateName, solution.MuseumMarathonTemplateName} {
+		template, exists := solution.SystemDayTemplate(name)
+		if !exists {
+			t.Errorf("built-in template %s does not exist", name)
+			continue
+		}
+		// built-in templates must satisfy the rules of user-defined templates apart from the reserved names
+		template.Name = "copy of " + name
+		if err := template.Validate(); err != nil {
+			t.Errorf("built-in template %s is invalid: %s", name, err.Error())
+		}
+	}
+
+	if _, exists := solution.SystemDayTemplate(" Foodie "); !exists {
+		t.Error("template names should be case-insensitive")
+	}
+}
+
+func TestValidateDayTemplate(t *testing.T) {
+	stayTime := func(startHour, endHour int) POI.TimeInterval {
+		return POI.TimeInterval{Start: POI.NewTimeOfDay(startHour, 0), End: POI.NewTimeOfDay(endHour, 0)}
+	}
+
+	invalidTemplates := map[string]solution.DayTemplate{
+		"reserved name": {Name: solution.RelaxedTemplateName, Slots: []solution.SlotTemplate{{EvOption: "E", StayTimes: []POI.TimeInterval{stayTime(9, 10)}}}},
+		"invalid name":  {Name: "brunch!", Slots: []solution.SlotTemplate{{EvOption: "E", StayTimes: []POI.TimeInterval{stayTime(9, 10)}}}},
+		"no slots":      {Name: "empty"},
+		"invalid tag":   {Name: "shopping", Slots: []solution.SlotTemplate{{EvOption: "S", StayTimes: []POI.TimeInterval{stayTime(9, 10)}}}},
+		"tag mismatch":  {Name: "mismatch", Slots: []solution.SlotTemplate{{EvOption: "EV", StayTimes: []POI.TimeInterval{stayTime(9, 10)}}}},
+		"overlapping": {Name: "overlap", Slots: []solution.SlotTemplate{
+			{EvOption: "E", StayTimes: []POI.TimeInterval{stayTime(9, 11)}},
+			{EvOption: "V", StayTimes: []POI.TimeInterval{stayTime(10, 12)}},
+		}},
+	}
+	for reason, template := range invalidTemplates {
+		if err := template.Validate(); err == nil {
+			t.Errorf("expected template with %s to be invalid", reason)
+		}
+	}
+
+	template := solution.DayTemplate{Name: "brunch and beach", Slots: []solution.SlotTemplate{
+		{EvOption: "ev", StayTimes: []POI.TimeInterval{stayTime(10, 12), stayTime(12, 16)}},
+	}}
+	if err := template.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := template.PlanningRequest("Santa Monica,USA", POI.DateSunday, 3)
+	if len(req.SlotRequests) != 1 || req.SlotRequests[0].EvOption != "EV" || req.SlotRequests[0].Location != "Santa Monica,USA" {
+		t.Errorf("unexpected slot requests %+v", req.SlotRequests)
+	}
+	if req.SlotRequests[0].StayTimes[1].Slot != stayTime(12, 16) || req.Weekday != POI.DateSunday || req.NumResults != 3 {
+		t.Errorf("unexpected planning request %+v", req)
+	}
+}
@@ -38,6 +38,14 @@ func TestFindBestSolutions(t *testing.T) {
 		}
 	}
 
+	// test best solutions are sorted by score in descending order
+	for idx := 1; idx < len(bestSolutions); idx++ {
+		if bestSolutions[idx].Score > bestSolutions[idx-1].Score {
+			t.Errorf("solution with score %.2f should be ranked before solution with score %.2f",
+				bestSolutions[idx].Score, bestSolutions[idx-1].Score)
+		}
+	}
+
 	// test extreme
 	numResults = uint64(10000)
 	bestSolutions = solution.FindBestSolutions(solutionCandidates, numResults)
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextDateOnWeekday(t *testing.T) {
+	now := time.Date(2020, time.October, 1, 15, 30, 0, 0, time.UTC) // a Thursday
+	expected := map[POI.Weekday]string{
+		POI.DateThursday:  "2020-10-01",
+		POI.DateSaturday:  "2020-10-03",
+		POI.DateWednesday: "2020-10-07",
+	}
+	for weekday, expectedDate := range expected {
+		if date := planner.NextDateOnWeekday(now, weekday).Format("2006-01-02"); date != expectedDate {
+			t.Errorf("expected date %s, got %s", expectedDate, date)
+		}
+	}
+}
+
+func TestToICalendar(t *testing.T) {
+	resp := planner.PlanningResponse{
+		TravelDestination: "San Francisco",
+		TimeZone:          "America/Los_Angeles",
+		PlanID:            "abc",
+		Places: [][]planner.TimeSectionPlaces{{
+			{Places: []planner.TimeSectionPlace{
+				{PlaceName: "Tartine Bakery", StartTime: POI.NewTimeOfDay(9, 0), EndTime: POI.NewTimeOfDay(10, 15), Address: "600 Guerrero St, San Francisco, CA 94110", URL: "https://maps.google.com/?cid=1"},
+				{PlaceName: "Golden Gate Park", StartTime: POI.NewTimeOfDay(10, 15), EndTime: POI.NewTimeOfDay(12, 0), Address: "San Francisco, CA", URL: "https://maps.google.com/?cid=2"},
+			}},
+		}},
+	}
+	date := time.Date(2020, time.October, 3, 0, 0, 0, 0, time.UTC)
+
+	calendar, err := planner.ToICalendar(resp, 0, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(calendar, "END:VCALENDAR\r\n") {
+		t.Error("calendar must begin and end with VCALENDAR")
+	}
+	if numEvents := strings.Count(calendar, "BEGIN:VEVENT"); numEvents != 2 {
+		t.Errorf("expected 2 events, got %d", numEvents)
+	}
+	// 9 AM Pacific Daylight Time is 4 PM UTC
+	if !strings.Contains(calendar, "DTSTART:20201003T160000Z\r\n") {
+		t.Error("event start time is not converted from the destination time zone to UTC")
+	}
+	if !strings.Contains(calendar, "DTEND:20201003T171500Z\r\n") {
+		t.Error("event end time does not keep the minutes")
+	}
+	// unfold long content lines before checking property values
+	unfoldedCalendar := strings.ReplaceAll(calendar, "\r\n ", "")
+	if !strings.Contains(unfoldedCalendar, `LOCATION;ALTREP="https://maps.google.com/?cid=1":600 Guerrero St\, San Francisco\, CA 94110`) {
+		t.Error("event location is not escaped or misses the place URL")
+	}
+	for _, line := range strings.Split(calendar, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line exceeds 75 octets: %s", line)
+		}
+	}
+
+	if _, err = planner.ToICalendar(resp, 1, date); err == nil {
+		t.Error("expected an error for a plan option that does not exist")
+	}
+}
@@ -7,16 +7,16 @@ import (
 
 func TestInsertInterval(t *testing.T) {
 	gt := POI.GoogleMapsTimeIntervals{}
-	gt.InsertTimeInterval(POI.TimeInterval{10, 20})
-	gt.InsertTimeInterval(POI.TimeInterval{20, 23})
-	gt.InsertTimeInterval(POI.TimeInterval{0, 7})
-	gt.InsertTimeInterval(POI.TimeInterval{7, 10})
+	gt.InsertTimeInterval(POI.TimeInterval{Start: POI.NewTimeOfDay(10, 0), End: POI.NewTimeOfDay(20, 0)})
+	gt.InsertTimeInterval(POI.TimeInterval{Start: POI.NewTimeOfDay(20, 0), End: POI.NewTimeOfDay(23, 0)})
+	gt.InsertTimeInterval(POI.TimeInterval{Start: POI.NewTimeOfDay(0, 0), End: POI.NewTimeOfDay(7, 0)})
+	gt.InsertTimeInterval(POI.TimeInterval{Start: POI.NewTimeOfDay(7, 0), End: POI.NewTimeOfDay(10, 0)})
 	expected := [][2]uint{{0, 7}, {7, 10}, {10, 20}, {20, 23}}
 	if len(expected) != gt.NumIntervals() {
 		t.Errorf("Incorrect number of intervals. Expected: %d, got: %d", len(expected), gt.NumIntervals())
 	}
 	for idx, interval := range *gt.GetAllIntervals() {
-		if interval.Start != POI.Hour(expected[idx][0]) || interval.End != POI.Hour(expected[idx][1]) {
+		if interval.Start != POI.NewTimeOfDay(int(expected[idx][0]), 0) || interval.End != POI.NewTimeOfDay(int(expected[idx][1]), 0) {
 			t.Errorf("Interval setting for %d-th interval is wrong", idx)
 		}
 	}
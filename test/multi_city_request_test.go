@@ -0,0 +1,29 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"testing"
+)
+
+// stops without times take the whole day from 09:00 to 22:00
+func TestMultiCityStopsWithDefaultTimes(t *testing.T) {
+	earlyStop := planner.PlanningStop{Country: "USA", City: "San Diego", StartTime: POI.NewTimeOfDay(6, 0),
+		EndTime: POI.NewTimeOfDay(8, 0), NumVisit: 1, NumEatery: 1}
+	defaultStop := planner.PlanningStop{Country: "USA", City: "Los Angeles"}
+	lateStop := planner.PlanningStop{Country: "USA", City: "Santa Monica", StartTime: POI.NewTimeOfDay(13, 0),
+		EndTime: POI.NewTimeOfDay(18, 0), NumVisit: 1, NumEatery: 1}
+
+	req := planner.PlanningPostRequest{Weekday: POI.DateSaturday, Stops: []planner.PlanningStop{earlyStop, defaultStop}}
+	planningReq, err := planner.ProcessPlanningPostRequest(&req, planner.DefaultPlannerConfig())
+	assert.Nil(t, err)
+	lastSlot := planningReq.SlotRequests[len(planningReq.SlotRequests)-1]
+	assert.Equal(t, "Los Angeles,USA", lastSlot.Location)
+	assert.Equal(t, POI.NewTimeOfDay(22, 0), lastSlot.StayTimes[len(lastSlot.StayTimes)-1].Slot.End)
+
+	// the default time range of the first stop overlaps the second stop
+	req = planner.PlanningPostRequest{Weekday: POI.DateSaturday, Stops: []planner.PlanningStop{defaultStop, lateStop}}
+	_, err = planner.ProcessPlanningPostRequest(&req, planner.DefaultPlannerConfig())
+	assert.EqualError(t, err, "stop 2 cannot start before the previous stop ends")
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"testing"
+	"time"
+)
+
+func planningStreamEntries(start time.Time) []iowrappers.PlanningStreamEntry {
+	return []iowrappers.PlanningStreamEntry{
+		{Time: start.Add(5 * time.Minute), User: "ada", City: "chicago", Country: "us"},
+		{Time: start.Add(10 * time.Minute), User: "ada", City: "chicago", Country: "us", APIKeyID: "key1"},
+		{Time: start.Add(20 * time.Minute), User: "grace", City: "chicago", Country: "us"},
+		{Time: start.Add(70 * time.Minute), User: "grace", City: "paris", Country: "france"},
+		{Time: start.Add(75 * time.Minute), User: "grace", City: "tokyo", Country: "japan"},
+	}
+}
+
+func TestTopCities(t *testing.T) {
+	start := time.Date(2020, 10, 3, 9, 0, 0, 0, time.UTC)
+	cities := planner.TopCities(planningStreamEntries(start), 2)
+	assert.Equal(t, []planner.CityActivity{
+		{Country: "us", City: "chicago", Requests: 3, UniqueUsers: 2},
+		{Country: "france", City: "paris", Requests: 1, UniqueUsers: 1},
+	}, cities)
+}
+
+func TestHourlyRequestVolume(t *testing.T) {
+	start := time.Date(2020, 10, 3, 9, 0, 0, 0, time.UTC)
+	volume := planner.HourlyRequestVolume(planningStreamEntries(start), start, start.Add(3*time.Hour))
+	assert.Equal(t, []planner.HourlyVolume{
+		{Hour: start, Requests: 3, UniqueUsers: 2},
+		{Hour: start.Add(time.Hour), Requests: 2, UniqueUsers: 1},
+		{Hour: start.Add(2 * time.Hour), Requests: 0, UniqueUsers: 0},
+	}, volume)
+}
+
+func TestUserActivities(t *testing.T) {
+	start := time.Date(2020, 10, 3, 9, 0, 0, 0, time.UTC)
+	activities := planner.UserActivities(planningStreamEntries(start), 10)
+	assert.Len(t, activities, 2)
+
+	assert.Equal(t, "grace", activities[0].Username)
+	assert.Equal(t, 3, activities[0].Requests)
+	assert.Equal(t, 3, activities[0].NumCities)
+	assert.Equal(t, start.Add(20*time.Minute), activities[0].FirstRequestAt)
+	assert.Equal(t, start.Add(75*time.Minute), activities[0].LastRequestAt)
+
+	assert.Equal(t, "ada", activities[1].Username)
+	assert.Equal(t, 1, activities[1].APIKeyRequests)
+	assert.Equal(t, []planner.CityActivity{{Country: "us", City: "chicago", Requests: 2, UniqueUsers: 1}}, activities[1].TopCities)
+}
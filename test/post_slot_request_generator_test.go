@@ -12,13 +12,13 @@ func TestPostSlotRequestGenerator(t *testing.T) {
 		Country:   "USA",
 		City:      "Seattle",
 		Weekday:   0,
-		StartTime: 8,
-		EndTime:   20,
+		StartTime: POI.NewTimeOfDay(8, 0),
+		EndTime:   POI.NewTimeOfDay(20, 0),
 		NumVisit:  4,
 		NumEatery: 3,
 	}
 
-	slotRequests := planner.GenSlotRequests(req)
+	slotRequests := planner.GenSlotRequests(req, planner.MaxPlacesPerSlot)
 
 	if len(slotRequests) != 3 {
 		t.Errorf("wrong number of slot requests generated. expected: %d, got: %d", 3, len(slotRequests))
@@ -33,10 +33,10 @@ func TestPostSlotRequestGenerator(t *testing.T) {
 	}
 
 	var expectedFirstSlotStayTimes []matching.TimeSlot
-	expectedFirstSlotStayTimes = append(expectedFirstSlotStayTimes, matching.TimeSlot{Slot: POI.TimeInterval{8, 9}})
-	expectedFirstSlotStayTimes = append(expectedFirstSlotStayTimes, matching.TimeSlot{Slot: POI.TimeInterval{9, 12}})
-	expectedFirstSlotStayTimes = append(expectedFirstSlotStayTimes, matching.TimeSlot{Slot: POI.TimeInterval{12, 13}})
-	expectedFirstSlotStayTimes = append(expectedFirstSlotStayTimes, matching.TimeSlot{Slot: POI.TimeInterval{13, 15}})
+	expectedFirstSlotStayTimes = append(expectedFirstSlotStayTimes, matching.TimeSlot{Slot: POI.TimeInterval{Start: POI.NewTimeOfDay(8, 0), End: POI.NewTimeOfDay(9, 0)}})
+	expectedFirstSlotStayTimes = append(expectedFirstSlotStayTimes, matching.TimeSlot{Slot: POI.TimeInterval{Start: POI.NewTimeOfDay(9, 0), End: POI.NewTimeOfDay(12, 0)}})
+	expectedFirstSlotStayTimes = append(expectedFirstSlotStayTimes, matching.TimeSlot{Slot: POI.TimeInterval{Start: POI.NewTimeOfDay(12, 0), End: POI.NewTimeOfDay(13, 0)}})
+	expectedFirstSlotStayTimes = append(expectedFirstSlotStayTimes, matching.TimeSlot{Slot: POI.TimeInterval{Start: POI.NewTimeOfDay(13, 0), End: POI.NewTimeOfDay(15, 0)}})
 
 	for idx, expectedFirstSlotStayTime := range expectedFirstSlotStayTimes {
 		if slotRequests[0].StayTimes[idx] != expectedFirstSlotStayTime {
@@ -45,3 +45,38 @@ func TestPostSlotRequestGenerator(t *testing.T) {
 		}
 	}
 }
+
+func TestPostSlotRequestGeneratorWithMinutes(t *testing.T) {
+	req := planner.PlanningPostRequest{
+		Country:   "USA",
+		City:      "Seattle",
+		StartTime: POI.NewTimeOfDay(10, 45),
+		EndTime:   POI.NewTimeOfDay(14, 15),
+		NumVisit:  2,
+		NumEatery: 1,
+	}
+
+	slotRequests := planner.GenSlotRequests(req, planner.MaxPlacesPerSlot)
+
+	// 3.5 hours are allocated hour by hour, and the last half an hour goes to the first group
+	expectedStayTimes := [][]POI.TimeInterval{
+		{
+			{Start: POI.NewTimeOfDay(10, 45), End: POI.NewTimeOfDay(11, 45)},
+			{Start: POI.NewTimeOfDay(11, 45), End: POI.NewTimeOfDay(13, 15)},
+		},
+		{
+			{Start: POI.NewTimeOfDay(13, 15), End: POI.NewTimeOfDay(14, 15)},
+		},
+	}
+	if len(slotRequests) != len(expectedStayTimes) {
+		t.Fatalf("wrong number of slot requests generated. expected: %d, got: %d", len(expectedStayTimes), len(slotRequests))
+	}
+	for slotIdx, stayTimes := range expectedStayTimes {
+		for idx, expectedInterval := range stayTimes {
+			if slotRequests[slotIdx].StayTimes[idx].Slot != expectedInterval {
+				t.Errorf("expected stay times does not match. expected: %v, got: %v",
+					expectedInterval, slotRequests[slotIdx].StayTimes[idx].Slot)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/matching"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"testing"
+)
+
+func TestPriceLevels(t *testing.T) {
+	defaultPriceLevels := matching.DefaultPriceLevels()
+	assert.Equal(t, matching.PriceLevel3, defaultPriceLevels.Price(3))
+	assert.Equal(t, matching.PriceLevelDefault, defaultPriceLevels.Price(5))
+	assert.Equal(t, matching.PriceLevelDefault, defaultPriceLevels.Price(-1))
+
+	priceLevels := matching.PriceLevels{Levels: [5]float64{0, 5, 20, 40, 80}, Default: 10}
+	place := matching.CreatePricedPlace(POI.Place{PriceLevel: 2}, POI.PlaceCategoryEatery, priceLevels)
+	assert.Equal(t, 20.0, place.GetPrice())
+
+	// free places are scored with the average price of the configured price levels
+	freePlace := matching.CreatePricedPlace(POI.Place{PriceLevel: 0, Rating: 4}, POI.PlaceCategoryVisit, priceLevels)
+	assert.Equal(t, 0.15, matching.Score([]matching.Place{freePlace}, priceLevels))
+	assert.Equal(t, 0.1, matching.Score([]matching.Place{freePlace}, defaultPriceLevels))
+}
+
+func TestSolverConfigFingerprint(t *testing.T) {
+	config := solution.DefaultSolverConfig()
+	assert.Equal(t, config.Fingerprint(), solution.DefaultSolverConfig().Fingerprint())
+
+	// slot solutions computed with other configs are cached under other keys
+	config.PriceLevels.Levels[2] = 20
+	assert.NotEqual(t, solution.DefaultSolverConfig().Fingerprint(), config.Fingerprint())
+	config = solution.DefaultSolverConfig()
+	config.CandidateQueueLength++
+	assert.NotEqual(t, solution.DefaultSolverConfig().Fingerprint(), config.Fingerprint())
+}
+
+func TestCandidateQueueLength(t *testing.T) {
+	candidates := make([]solution.SlotSolutionCandidate, 0)
+	for i := 0; i < 10; i++ {
+		candidates = append(candidates, solution.SlotSolutionCandidate{Score: float64(i)})
+	}
+
+	res := solution.FindBestCandidates(candidates, 3)
+	assert.Len(t, res, 3)
+	assert.Equal(t, 7.0, res[0].Score)
+}
+
+func TestSlotRequestsWithMaxPlacesPerSlot(t *testing.T) {
+	req := planner.PlanningPostRequest{
+		Country:   "USA",
+		City:      "Seattle",
+		StartTime: POI.NewTimeOfDay(8, 0),
+		EndTime:   POI.NewTimeOfDay(20, 0),
+		NumVisit:  4,
+		NumEatery: 3,
+	}
+
+	// groups are not combined if a slot can have only one place
+	slotRequests := planner.GenSlotRequests(req, 1)
+	assert.Len(t, slotRequests, 4)
+	for _, slotRequest := range slotRequests {
+		assert.LessOrEqual(t, len(slotRequest.EvOption), 2)
+	}
+}
@@ -0,0 +1,156 @@
+package redis_client_mocks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/user"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var tokenRegex = regexp.MustCompile(`token=([0-9a-f]+)`)
+
+// keep emails instead of sending them
+type captureMailer struct {
+	mutex sync.Mutex
+	sent  []iowrappers.Mail
+}
+
+func (mailer *captureMailer) Send(mail iowrappers.Mail) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+	mailer.sent = append(mailer.sent, mail)
+	return nil
+}
+
+func (mailer *captureMailer) Sent() []iowrappers.Mail {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+	return append([]iowrappers.Mail{}, mailer.sent...)
+}
+
+func TestUserTokensAreUsedOnce(t *testing.T) {
+	token, err := RedisClient.CreateUserToken(iowrappers.PasswordResetToken, "ada_lovelace", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tokens of other purposes are different tokens
+	_, err = RedisClient.ConsumeUserToken(iowrappers.EmailVerificationToken, token)
+	assert.NotNil(t, err)
+
+	username, err := RedisClient.ConsumeUserToken(iowrappers.PasswordResetToken, token)
+	assert.Nil(t, err)
+	assert.Equal(t, "ada_lovelace", username)
+
+	_, err = RedisClient.ConsumeUserToken(iowrappers.PasswordResetToken, token)
+	assert.NotNil(t, err)
+
+	token, err = RedisClient.CreateUserToken(iowrappers.PasswordResetToken, "ada_lovelace", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RedisMockSvr.FastForward(time.Hour)
+	_, err = RedisClient.ConsumeUserToken(iowrappers.PasswordResetToken, token)
+	assert.NotNil(t, err)
+}
+
+func TestEmailVerificationAndPasswordReset(t *testing.T) {
+	mailer := &captureMailer{}
+	myPlanner := planner.MyPlanner{RedisClient: RedisClient, Mailer: mailer, PublicURL: "https://unwind.dev"}
+	handler := myPlanner.SetupRouter("10000").Handler
+
+	send := func(method string, target string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := send(http.MethodPost, "/v1/signup",
+		`{"username": "hedy_lamarr", "password": "frequency", "email": "hedy@example.com", "email_verified": true}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	u, err := RedisClient.FindUser("hedy_lamarr")
+	assert.Nil(t, err)
+	assert.False(t, u.EmailVerified)
+
+	// verification
+	if !assert.Len(t, mailer.Sent(), 1) {
+		return
+	}
+	verificationMail := mailer.Sent()[0]
+	assert.Equal(t, "hedy@example.com", verificationMail.To)
+	assert.Contains(t, verificationMail.Body, "https://unwind.dev/v1/email/verify?token=")
+	verificationToken := tokenRegex.FindStringSubmatch(verificationMail.Body)[1]
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/v1/email/verify?token="+url.QueryEscape(verificationToken), "").Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/v1/email/verify?token="+url.QueryEscape(verificationToken), "").Code)
+	u, _ = RedisClient.FindUser("hedy_lamarr")
+	assert.True(t, u.EmailVerified)
+
+	// unknown email addresses get the same response
+	assert.Equal(t, http.StatusAccepted, send(http.MethodPost, "/v1/password/forgot", `{"email": "nobody@example.com"}`).Code)
+	assert.Equal(t, http.StatusAccepted, send(http.MethodPost, "/v1/password/forgot", `{"email": "Hedy@example.com"}`).Code)
+	assert.Eventually(t, func() bool { return len(mailer.Sent()) == 2 }, time.Second, 10*time.Millisecond)
+	resetToken := tokenRegex.FindStringSubmatch(mailer.Sent()[1].Body)[1]
+
+	// the link in the email opens the password reset page without using the token
+	assert.Contains(t, mailer.Sent()[1].Body, "https://unwind.dev/v1/password/reset?token="+resetToken)
+	recorder = send(http.MethodGet, "/v1/password/reset?token="+url.QueryEscape(resetToken), "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `value="`+resetToken+`"`)
+
+	// sessions and API keys from before the reset end
+	tokens, err := RedisClient.Authenticate(user.Credential{Username: "hedy_lamarr", Password: "frequency"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKey, _, err := RedisClient.CreateAPIKey("hedy_lamarr", "torpedo guidance")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/v1/password/reset", `{"token": "`+resetToken+`", "password": "short"}`).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/v1/password/reset", `{"token": "`+resetToken+`", "password": "spread spectrum"}`).Code)
+	_, err = RedisClient.ValidateToken(tokens.AccessToken, user.AccessToken)
+	assert.NotNil(t, err)
+	_, err = RedisClient.ValidateToken(tokens.RefreshToken, user.RefreshToken)
+	assert.NotNil(t, err)
+	_, _, err = RedisClient.AuthenticateAPIKey(apiKey)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/v1/password/reset", `{"token": "`+resetToken+`", "password": "spread spectrum 2"}`).Code)
+
+	_, err = RedisClient.Authenticate(user.Credential{Username: "hedy_lamarr", Password: "frequency"})
+	assert.NotNil(t, err)
+	tokens, err = RedisClient.Authenticate(user.Credential{Username: "hedy_lamarr", Password: "spread spectrum"})
+	assert.Nil(t, err)
+	_, err = RedisClient.ValidateToken(tokens.AccessToken, user.AccessToken)
+	assert.Nil(t, err)
+}
+
+func TestPasswordResetForm(t *testing.T) {
+	myPlanner := planner.MyPlanner{RedisClient: RedisClient, Mailer: &captureMailer{}}
+	if err := RedisClient.CreateUser(user.User{Username: "radia_perlman", Password: "spanning tree"}); err != nil {
+		t.Fatal(err)
+	}
+	token, err := RedisClient.CreateUserToken(iowrappers.PasswordResetToken, "radia_perlman", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{"token": {token}, "password": {"link state routing"}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/password/reset", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	myPlanner.SetupRouter("10000").Handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	_, err = RedisClient.Authenticate(user.Credential{Username: "radia_perlman", Password: "link state routing"})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,112 @@
+package redis_client_mocks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/user"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestAPIKeys(t *testing.T) {
+	username := "alan_turing"
+	key, record, err := RedisClient.CreateAPIKey(username, "nightly script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, iowrappers.IsAPIKey(key))
+	assert.Equal(t, "nightly script", record.Name)
+
+	owner, keyId, err := RedisClient.AuthenticateAPIKey(key)
+	assert.Nil(t, err)
+	assert.Equal(t, username, owner)
+	assert.Equal(t, record.ID, keyId)
+
+	records, err := RedisClient.ListAPIKeys(username)
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, record.ID, records[0].ID)
+
+	_, _, err = RedisClient.AuthenticateAPIKey(key + "0")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, RedisClient.RevokeAPIKey(username, record.ID))
+	_, _, err = RedisClient.AuthenticateAPIKey(key)
+	assert.NotNil(t, err)
+	assert.NotNil(t, RedisClient.RevokeAPIKey(username, record.ID))
+}
+
+func TestAPIKeysLimit(t *testing.T) {
+	username := "barbara_liskov"
+	for idx := 0; idx < iowrappers.MaxAPIKeysPerUser; idx++ {
+		if _, _, err := RedisClient.CreateAPIKey(username, "key "+strconv.Itoa(idx)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _, err := RedisClient.CreateAPIKey(username, "one too many")
+	assert.NotNil(t, err)
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	myPlanner := planner.MyPlanner{RedisClient: RedisClient}
+	if err := RedisClient.CreateUser(user.User{Username: "edsger_dijkstra", Password: "shortest path"}); err != nil {
+		t.Fatal(err)
+	}
+	key, record, err := RedisClient.CreateAPIKey("edsger_dijkstra", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/plans", nil)
+	req.Header.Set(planner.APIKeyHeader, key)
+	username, err := myPlanner.UserAuthentication(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "edsger_dijkstra", username)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/plans", nil)
+	req.Header.Set("Authorization", "Bearer "+key)
+	username, err = myPlanner.UserAuthentication(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "edsger_dijkstra", username)
+
+	assert.Nil(t, RedisClient.RevokeAPIKey("edsger_dijkstra", record.ID))
+	_, err = myPlanner.UserAuthentication(req)
+	assert.NotNil(t, err)
+}
+
+func TestAPIKeysOfUsersWithColons(t *testing.T) {
+	// the API keys of the user "john:api_keys" are not the user "john"
+	if _, _, err := RedisClient.CreateAPIKey("john", "laptop"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := RedisClient.ListAPIKeys("john:api_keys")
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+}
+
+func TestManagingAPIKeysRequiresActiveUser(t *testing.T) {
+	myPlanner := planner.MyPlanner{RedisClient: RedisClient}
+	username := "frances_allen"
+	if err := RedisClient.CreateUser(user.User{Username: username, Password: "optimizing compilers"}); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := issueTokens(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := myPlanner.SetupRouter("10000").Handler
+	listKeys := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/me/api-keys", nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, listKeys())
+	assert.Nil(t, RedisClient.SetUserDisabled(username, true))
+	assert.Equal(t, http.StatusUnauthorized, listKeys())
+}
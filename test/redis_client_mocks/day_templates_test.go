@@ -0,0 +1,64 @@
+package redis_client_mocks
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"strconv"
+	"testing"
+)
+
+type testDayTemplate struct {
+	Name     string   `json:"name"`
+	EvOption []string `json:"ev_option"`
+}
+
+func TestDayTemplates(t *testing.T) {
+	username := "julia_child"
+	templates := []testDayTemplate{
+		{Name: "lazy sunday", EvOption: []string{"E", "V"}},
+		{Name: "bakery crawl", EvOption: []string{"EEE", "E"}},
+	}
+	for _, template := range templates {
+		if err := RedisClient.SaveDayTemplate(username, template.Name, template); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var template testDayTemplate
+	if err := RedisClient.GetDayTemplate(username, "lazy sunday", &template); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, templates[0], template)
+
+	// templates are listed by name
+	listed, err := RedisClient.ListDayTemplates(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(listed))
+	if err = json.Unmarshal(listed[0], &template); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, templates[1], template)
+
+	// templates are private to their users
+	assert.NotNil(t, RedisClient.GetDayTemplate("gordon_ramsay", "lazy sunday", &template))
+
+	assert.Nil(t, RedisClient.DeleteDayTemplate(username, "lazy sunday"))
+	assert.NotNil(t, RedisClient.DeleteDayTemplate(username, "lazy sunday"))
+	assert.NotNil(t, RedisClient.GetDayTemplate(username, "lazy sunday", &template))
+}
+
+func TestDayTemplatesLimit(t *testing.T) {
+	username := "anthony_bourdain"
+	for idx := 0; idx < iowrappers.MaxDayTemplatesPerUser; idx++ {
+		name := "template " + strconv.Itoa(idx)
+		if err := RedisClient.SaveDayTemplate(username, name, testDayTemplate{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.NotNil(t, RedisClient.SaveDayTemplate(username, "one too many", testDayTemplate{Name: "one too many"}))
+	// replacing an existing template is allowed at the limit
+	assert.Nil(t, RedisClient.SaveDayTemplate(username, "template 0", testDayTemplate{Name: "template 0"}))
+}
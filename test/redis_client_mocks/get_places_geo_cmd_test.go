@@ -66,7 +66,7 @@ func TestGetPlaces(t *testing.T) {
 		MinNumResults: 1,
 	}
 
-	cachedVisitPlaces, _ := RedisClient.NearbySearch(&placeSearchRequest)
+	cachedVisitPlaces, _ := RedisClient.NearbySearch(&placeSearchRequest, iowrappers.MaxSearchRadius)
 
 	if len(cachedVisitPlaces) != 1 || cachedVisitPlaces[0].ID != places[0].ID {
 		t.Logf("number of nearby visit places obtained from Redis is %d", len(cachedVisitPlaces))
@@ -82,15 +82,26 @@ func TestGetPlaces(t *testing.T) {
 		MinNumResults: 2,
 	}
 
-	cachedEateryPlaces, _ := RedisClient.NearbySearch(&placeSearchRequest)
+	cachedEateryPlaces, _ := RedisClient.NearbySearch(&placeSearchRequest, iowrappers.MaxSearchRadius)
 
 	if len(cachedEateryPlaces) != 1 || cachedEateryPlaces[0].ID != places[2].ID {
 		t.Logf("number of nearby eatery places obtained from Redis is %d", len(cachedEateryPlaces))
 		t.Error("failed to get cached Eatery place")
 	}
 
+	// the search radius is limited to the max search radius
+	placeSearchRequest.Radius = uint(50000)
+	cachedEateryPlaces, _ = RedisClient.NearbySearch(&placeSearchRequest, 5000)
+	if len(cachedEateryPlaces) != 1 || placeSearchRequest.Radius != 50000 {
+		t.Error("search radius should be limited to the max search radius without changing the request")
+	}
+	cachedEateryPlaces, _ = RedisClient.NearbySearch(&placeSearchRequest, 50000)
+	if len(cachedEateryPlaces) != 2 {
+		t.Error("failed to get cached Eatery places within the max search radius")
+	}
+
 	// expect to return empty slice if total number of cached places in a category is less than requested minimum
-	cachedVisitPlaces, _ = RedisClient.NearbySearch(&iowrappers.PlaceSearchRequest{MinNumResults: 2, PlaceCat: "Visit"})
+	cachedVisitPlaces, _ = RedisClient.NearbySearch(&iowrappers.PlaceSearchRequest{MinNumResults: 2, PlaceCat: "Visit"}, iowrappers.MaxSearchRadius)
 	if len(cachedVisitPlaces) != 0 {
 		t.Error("should return empty slice if total number of cached places in a category is less than requested minimum")
 	}
@@ -0,0 +1,65 @@
+package redis_client_mocks
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func readiness(t *testing.T, myPlanner planner.MyPlanner) (int, planner.ReadinessResponse) {
+	recorder := httptest.NewRecorder()
+	myPlanner.SetupRouter("10000").Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	resp := planner.ReadinessResponse{}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	return recorder.Code, resp
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	myPlanner := planner.MyPlanner{
+		RedisClient:        RedisClient,
+		HomeHTMLTemplate:   template.New("index"),
+		ResultHTMLTemplate: template.New("plan_layout"),
+		PlanningEvents:     make(chan iowrappers.PlanningEvent, 10),
+	}
+
+	recorder := httptest.NewRecorder()
+	myPlanner.SetupRouter("10000").Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	code, resp := readiness(t, myPlanner)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, planner.DependencyStatusOK, resp.Status)
+	assert.Equal(t, planner.DependencyStatusOK, resp.Checks["redis"].Status)
+	assert.Equal(t, planner.DependencyStatusOK, resp.Checks["templates"].Status)
+	assert.Equal(t, planner.DependencyStatusOK, resp.Checks["planning_events_queue"].Status)
+	// MongoDB and Maps key checks are not configured
+	assert.NotContains(t, resp.Checks, "mongo")
+	assert.NotContains(t, resp.Checks, "maps")
+
+	// planning events queue has a backlog
+	for i := 0; i < 9; i++ {
+		myPlanner.PlanningEvents <- iowrappers.PlanningEvent{}
+	}
+	code, resp = readiness(t, myPlanner)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, planner.DependencyStatusUnavailable, resp.Checks["planning_events_queue"].Status)
+	assert.Equal(t, planner.DependencyStatusOK, resp.Checks["redis"].Status)
+
+	// Redis is down and templates are not loaded
+	myPlanner.PlanningEvents = make(chan iowrappers.PlanningEvent, 10)
+	myPlanner.ResultHTMLTemplate = nil
+	unreachableRedisURL, _ := url.Parse("redis://127.0.0.1:1")
+	myPlanner.RedisClient = iowrappers.CreateRedisClient(unreachableRedisURL)
+	code, resp = readiness(t, myPlanner)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, planner.DependencyStatusUnavailable, resp.Status)
+	assert.Equal(t, planner.DependencyStatusUnavailable, resp.Checks["redis"].Status)
+	assert.Equal(t, planner.DependencyStatusUnavailable, resp.Checks["templates"].Status)
+	assert.NotEmpty(t, resp.Checks["redis"].Error)
+}
@@ -3,6 +3,7 @@ package redis_client_mocks
 import (
 	"github.com/alicebob/miniredis/v2"
 	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/user"
 	"net/url"
 )
 
@@ -17,3 +18,12 @@ func init() {
 	redisURL, _ := url.Parse(redisUrl)
 	RedisClient = iowrappers.CreateRedisClient(redisURL)
 }
+
+// issue tokens of the current token generation of an user without the password of the user
+func issueTokens(username string) (user.TokenPair, error) {
+	generation, err := RedisClient.TokenGeneration(username)
+	if err != nil {
+		return user.TokenPair{}, err
+	}
+	return iowrappers.IssueTokenPair(username, generation)
+}
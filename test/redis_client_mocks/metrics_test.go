@@ -0,0 +1,38 @@
+package redis_client_mocks
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	myPlanner := planner.MyPlanner{RedisClient: RedisClient}
+	handler := myPlanner.SetupRouter("10000").Handler
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/templates/standard", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// requests are labeled by the route template
+	assert.Contains(t, recorder.Body.String(),
+		`vacation_planner_http_request_duration_seconds_count{endpoint="/v1/templates/:name",method="GET",status="200"}`)
+}
+
+func TestSlotSolutionCacheMetrics(t *testing.T) {
+	cacheRequest := iowrappers.SlotSolutionCacheRequest{City: "Lisbon", Country: "Portugal", EVTags: []string{"E"}}
+	cacheResponse := iowrappers.SlotSolutionCacheResponse{}
+	cacheResponse.SlotSolutionCandidate = []iowrappers.SlotSolutionCandidateCache{{PlaceIds: []string{"1"}}}
+	RedisClient.CacheSlotSolution(cacheRequest, cacheResponse)
+
+	hits := testutil.ToFloat64(iowrappers.SlotSolutionCacheLookups.WithLabelValues("hit"))
+	RedisClient.GetMultiSlotSolutions([]iowrappers.SlotSolutionCacheRequest{cacheRequest})
+	assert.Equal(t, hits+1, testutil.ToFloat64(iowrappers.SlotSolutionCacheLookups.WithLabelValues("hit")))
+}
@@ -0,0 +1,66 @@
+package redis_client_mocks
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"testing"
+	"time"
+)
+
+func TestPlanJobs(t *testing.T) {
+	username := "steve_jobs"
+	jobId, err := RedisClient.CreatePlanJob(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := RedisClient.GetPlanJob(jobId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, username, job.Owner)
+	assert.Equal(t, iowrappers.PlanJobQueued, job.Status)
+	assert.Empty(t, job.Result)
+
+	result := testPlan{Destination: "Cupertino", Places: []string{"Apple Park Visitor Center"}}
+	if err = RedisClient.UpdatePlanJob(jobId, iowrappers.PlanJobDone, result, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err = RedisClient.GetPlanJob(jobId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, iowrappers.PlanJobDone, job.Status)
+	var jobResult testPlan
+	if err = json.Unmarshal(job.Result, &jobResult); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, result, jobResult)
+
+	// job state expires
+	RedisMockSvr.FastForward(iowrappers.PlanJobExpiration + time.Minute)
+	_, err = RedisClient.GetPlanJob(jobId)
+	assert.NotNil(t, err)
+}
+
+func TestStalePlanJobsFail(t *testing.T) {
+	jobId, err := RedisClient.CreatePlanJob("steve_jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the server instance of the job stopped updating it
+	RedisMockSvr.HSet("plan_job:"+jobId, "updated_at", time.Now().Add(-iowrappers.PlanJobTimeout-time.Minute).Format(time.RFC3339))
+	job, err := RedisClient.GetPlanJob(jobId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, iowrappers.PlanJobFailed, job.Status)
+	assert.Equal(t, iowrappers.PlanJobTimeoutErr, job.Err)
+
+	job, err = RedisClient.GetPlanJob(jobId)
+	assert.Nil(t, err)
+	assert.Equal(t, iowrappers.PlanJobFailed, job.Status)
+}
@@ -0,0 +1,108 @@
+package redis_client_mocks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitCounter(t *testing.T) {
+	window := time.Minute
+	for expectedCount := int64(1); expectedCount <= 3; expectedCount++ {
+		count, ttl, err := RedisClient.IncrementRateLimitCounter("user", "ada_lovelace", window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, expectedCount, count)
+		assert.True(t, ttl > 0 && ttl <= window)
+	}
+
+	// a new window starts after the counter expires
+	RedisMockSvr.FastForward(window)
+	count, _, err := RedisClient.IncrementRateLimitCounter("user", "ada_lovelace", window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRateLimitExemptions(t *testing.T) {
+	username := "grace_hopper"
+	assert.Nil(t, RedisClient.SetRateLimitExemption(username, true))
+	exempt, err := RedisClient.IsRateLimitExempt(username)
+	assert.Nil(t, err)
+	assert.True(t, exempt)
+
+	assert.Nil(t, RedisClient.SetRateLimitExemption(username, false))
+	exempt, err = RedisClient.IsRateLimitExempt(username)
+	assert.Nil(t, err)
+	assert.False(t, exempt)
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	myPlanner := planner.MyPlanner{
+		RedisClient: RedisClient,
+		RateLimits: planner.RateLimitConfig{
+			Window:         time.Minute,
+			GuestLimit:     5,
+			EndpointLimits: map[string]int64{"GET /v1/templates": 2},
+		},
+	}
+	handler := myPlanner.SetupRouter("10000").Handler
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/templates", nil)
+		req.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for idx := 0; idx < 2; idx++ {
+		assert.Equal(t, http.StatusOK, request("192.0.2.1:1234").Code)
+	}
+	resp := request("192.0.2.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+
+	// guests are limited by their IP addresses
+	assert.Equal(t, http.StatusOK, request("192.0.2.2:1234").Code)
+}
+
+func TestRateLimiterTrustedProxies(t *testing.T) {
+	myPlanner := planner.MyPlanner{
+		RedisClient: RedisClient,
+		RateLimits: planner.RateLimitConfig{
+			Window:         time.Minute,
+			GuestLimit:     1,
+			TrustedProxies: []string{"10.0.0.0/8"},
+		},
+	}
+	handler := myPlanner.SetupRouter("10000").Handler
+
+	request := func(remoteAddr string, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/templates", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// clients cannot reset their limits with forged headers
+	assert.Equal(t, http.StatusOK, request("198.51.100.1:1234", "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("198.51.100.1:1234", "203.0.113.2"))
+
+	// clients behind trusted proxies are limited by the forwarded addresses
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234", "198.51.100.2"))
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234", "198.51.100.3"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.2:1234", "198.51.100.3"))
+
+	// proxies append the addresses they receive requests from, spoofed addresses on the left are ignored
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234", "203.0.113.10, 198.51.100.4"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1:1234", "203.0.113.11, 198.51.100.4"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1:1234", "203.0.113.12, 198.51.100.4, 10.0.0.3"))
+}
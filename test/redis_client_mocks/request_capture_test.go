@@ -0,0 +1,87 @@
+package redis_client_mocks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/matching"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// requests are captured after the date check, with the slots of the request instead of the template that generated them
+func TestPlanningRequestsAreCapturedAfterValidation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "captured_requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, planner.CapturedRequestsFile)
+	recorder, err := planner.NewRequestRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redisURL, _ := url.Parse("redis://" + RedisMockSvr.Addr())
+	poiSearcher := &iowrappers.PoiSearcher{}
+	poiSearcher.Init("maps_api_key", redisURL, iowrappers.DefaultPoiSearcherConfig())
+	destination := planner.WarmUpDestination{Country: "Portugal", City: "Braga"}
+	cacheDestinationPlaces(destination, 41.5454, -8.4265, 6)
+	assert.Nil(t, RedisClient.SetTimeZone(iowrappers.GeocodeQuery{City: destination.City, Country: destination.Country}, "Europe/Lisbon"))
+	solverConfig := solution.DefaultSolverConfig()
+	solverConfig.TimeClusterMinResults = 6
+
+	myPlanner := &planner.MyPlanner{
+		RedisClient:     RedisClient,
+		PlanningEvents:  make(chan iowrappers.PlanningEvent, 1),
+		RequestRecorder: recorder,
+	}
+	myPlanner.Solver.Init(poiSearcher, solverConfig)
+
+	// slots of a day template of a user
+	stayTimes := []matching.TimeSlot{
+		{Slot: POI.TimeInterval{Start: POI.NewTimeOfDay(10, 0), End: POI.NewTimeOfDay(12, 0)}},
+		{Slot: POI.TimeInterval{Start: POI.NewTimeOfDay(12, 0), End: POI.NewTimeOfDay(13, 30)}},
+	}
+	slotRequests := func() []solution.SlotRequest {
+		return []solution.SlotRequest{{Location: "Braga,Portugal", EvOption: "VE", StayTimes: stayTimes}}
+	}
+	req := solution.PlanningRequest{
+		SlotRequests: slotRequests(),
+		SearchRadius: 5000,
+		Weekday:      POI.DateSaturday,
+		Date:         "2020-01-04",
+		NumResults:   3,
+	}
+	requester := planner.Requester{Username: planner.GuestUsername}
+	resp := myPlanner.Planning(&req, requester)
+	assert.Equal(t, uint(http.StatusBadRequest), resp.StatusCode)
+
+	req.Date = time.Now().AddDate(1, 0, 0).Format(POI.DateLayout)
+	req.Weekday = POI.GetWeekday(time.Now().AddDate(1, 0, 0))
+	myPlanner.Planning(&req, requester)
+	// the solver normalizes the locations of the request
+	req.SlotRequests = slotRequests()
+	assert.Nil(t, recorder.Close())
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	requests, err := planner.ReadCapturedRequests(file)
+	assert.Nil(t, err)
+	// requests in the past are not captured
+	if assert.Len(t, requests, 1) {
+		replayReq, replayErr := requests[0].PlanningRequest()
+		assert.Nil(t, replayErr)
+		assert.Equal(t, req, replayReq)
+	}
+}
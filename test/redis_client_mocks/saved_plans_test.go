@@ -0,0 +1,140 @@
+package redis_client_mocks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"testing"
+	"time"
+)
+
+type testPlan struct {
+	Destination string   `json:"destination"`
+	Places      []string `json:"places"`
+}
+
+func TestSavedPlans(t *testing.T) {
+	username := "bill_gates"
+	plans := []testPlan{
+		{Destination: "Seattle", Places: []string{"Space Needle", "Pike Place Market"}},
+		{Destination: "Portland", Places: []string{"Powell's City of Books"}},
+	}
+
+	planIds := make([]string, len(plans))
+	for idx, plan := range plans {
+		planId, err := RedisClient.SavePlan(username, plan.Destination, plan, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		planIds[idx] = planId
+	}
+
+	// retrieve a plan
+	var plan testPlan
+	record, err := RedisClient.GetPlan(planIds[0], &plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, username, record.Owner)
+	assert.Equal(t, plans[0], plan)
+
+	// list plans with pagination
+	records, total, err := RedisClient.ListUserPlans(username, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(len(plans)), total)
+	assert.Equal(t, 1, len(records))
+
+	// delete a plan
+	if err = RedisClient.DeletePlan(planIds[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = RedisClient.GetPlan(planIds[1], &plan); err == nil {
+		t.Error("expected an error when retrieving a deleted plan")
+	}
+	records, total, _ = RedisClient.ListUserPlans(username, 0, 10)
+	assert.Equal(t, int64(1), total)
+	if len(records) == 1 {
+		assert.Equal(t, planIds[0], records[0].ID)
+	}
+}
+
+func TestPlanShareTokens(t *testing.T) {
+	plan := testPlan{Destination: "Chicago", Places: []string{"Art Institute of Chicago"}}
+	planId, err := RedisClient.SavePlan("steve_jobs", plan.Destination, plan, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := RedisClient.CreatePlanShareToken(planId, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sharedPlanId, err := RedisClient.GetSharedPlanId(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, planId, sharedPlanId)
+
+	// share tokens expire
+	RedisMockSvr.FastForward(2 * time.Hour)
+	if _, err = RedisClient.GetSharedPlanId(token); err == nil {
+		t.Error("expected share token to expire")
+	}
+
+	// share tokens can be revoked
+	token, _ = RedisClient.CreatePlanShareToken(planId, 0)
+	if err = RedisClient.RevokePlanShareToken("another_plan", token); err == nil {
+		t.Error("expected an error when revoking a share token of another plan")
+	}
+	if err = RedisClient.RevokePlanShareToken(planId, token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = RedisClient.GetSharedPlanId(token); err == nil {
+		t.Error("expected share token to be revoked")
+	}
+
+	// deleting a plan revokes its share tokens
+	token, _ = RedisClient.CreatePlanShareToken(planId, 0)
+	_ = RedisClient.DeletePlan(planId)
+	if _, err = RedisClient.GetSharedPlanId(token); err == nil {
+		t.Error("expected share token to be revoked after the plan is deleted")
+	}
+}
+
+func TestSavedPlansRetention(t *testing.T) {
+	username := "plan_hoarder"
+	planIds := make([]string, 0)
+	for idx := 0; idx < iowrappers.MaxPlansPerUser+2; idx++ {
+		planId, err := RedisClient.SavePlan(username, "Boston", testPlan{Destination: "Boston"}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		planIds = append(planIds, planId)
+	}
+	_, total, err := RedisClient.ListUserPlans(username, 0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(iowrappers.MaxPlansPerUser), total)
+	// plans beyond the limit are deleted
+	var plan testPlan
+	numDeleted := 0
+	for _, planId := range planIds {
+		if _, err = RedisClient.GetPlan(planId, &plan); err != nil {
+			numDeleted++
+		}
+	}
+	assert.Equal(t, 2, numDeleted)
+
+	// temporary plans expire together with their history
+	planId, err := RedisClient.SavePlan("temporary", "Boston", testPlan{Destination: "Boston"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RedisMockSvr.FastForward(2 * time.Hour)
+	_, err = RedisClient.GetPlan(planId, &plan)
+	assert.NotNil(t, err)
+	_, total, err = RedisClient.ListUserPlans("temporary", 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), total)
+}
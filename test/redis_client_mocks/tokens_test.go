@@ -0,0 +1,153 @@
+package redis_client_mocks
+
+import (
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/user"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenValidation(t *testing.T) {
+	tokens, err := issueTokens("ada_lovelace")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := RedisClient.ValidateToken(tokens.AccessToken, user.AccessToken)
+	assert.Nil(t, err)
+	assert.Equal(t, "ada_lovelace", claims.Username)
+
+	// refresh tokens cannot be used as access tokens
+	_, err = RedisClient.ValidateToken(tokens.RefreshToken, user.AccessToken)
+	assert.NotNil(t, err)
+
+	// revoked tokens are rejected until they expire
+	assert.Nil(t, RedisClient.RevokeToken(claims))
+	_, err = RedisClient.ValidateToken(tokens.AccessToken, user.AccessToken)
+	assert.NotNil(t, err)
+	assert.True(t, RedisMockSvr.TTL("revoked_token:"+claims.Id) <= user.AccessTokenExpirationTime)
+}
+
+func TestTokenValidationRejectsForgedTokens(t *testing.T) {
+	now := time.Now()
+	claims := user.Claims{
+		Username:  "ada_lovelace",
+		TokenType: user.AccessToken,
+		StandardClaims: jwt.StandardClaims{
+			Id:        "forged",
+			Subject:   "ada_lovelace",
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		},
+	}
+
+	// unsigned tokens
+	unsignedToken, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = iowrappers.ParseToken(unsignedToken, user.AccessToken)
+	assert.NotNil(t, err)
+
+	// tokens signed with other keys
+	otherKeyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("not the signing secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = iowrappers.ParseToken(otherKeyToken, user.AccessToken)
+	assert.NotNil(t, err)
+
+	signingKey := []byte(os.Getenv("JWT_SIGNING_SECRET"))
+
+	// expired tokens
+	claims.ExpiresAt = now.Add(-time.Minute).Unix()
+	expiredToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = iowrappers.ParseToken(expiredToken, user.AccessToken)
+	assert.NotNil(t, err)
+
+	// tokens without expiration time
+	legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"username":       "ada_lovelace",
+		"StandardClaims": jwt.StandardClaims{ExpiresAt: now.Add(-time.Minute).Unix()},
+	}).SignedString(signingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = iowrappers.ParseToken(legacyToken, user.AccessToken)
+	assert.NotNil(t, err)
+}
+
+func TestUserAuthenticationUsesTokenClaims(t *testing.T) {
+	myPlanner := planner.MyPlanner{RedisClient: RedisClient}
+	if err := RedisClient.CreateUser(user.User{Username: "ada_lovelace", Password: "analytical engine"}); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := issueTokens("ada_lovelace")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the username cookie is ignored
+	req := httptest.NewRequest(http.MethodGet, "/v1/plans", nil)
+	req.AddCookie(&http.Cookie{Name: planner.AccessTokenCookie, Value: tokens.AccessToken})
+	req.AddCookie(&http.Cookie{Name: "Username", Value: "grace_hopper"})
+	username, err := myPlanner.UserAuthentication(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "ada_lovelace", username)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/plans", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	username, err = myPlanner.UserAuthentication(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "ada_lovelace", username)
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	myPlanner := planner.MyPlanner{RedisClient: RedisClient}
+	handler := myPlanner.SetupRouter("10000").Handler
+	if err := RedisClient.CreateUser(user.User{Username: "alan_kay", Password: "smalltalk"}); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := RedisClient.Authenticate(user.Credential{Username: "alan_kay", Password: "smalltalk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(url string, body string, accessToken string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	refreshBody := `{"refresh_token": "` + tokens.RefreshToken + `"}`
+	recorder := post("/v1/token/refresh", refreshBody, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	refreshed := planner.UserLoginResponse{}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &refreshed))
+	assert.Equal(t, "alan_kay", refreshed.Username)
+
+	// refresh tokens are rotated
+	assert.Equal(t, http.StatusUnauthorized, post("/v1/token/refresh", refreshBody, "").Code)
+
+	recorder = post("/v1/logout", `{"refresh_token": "`+refreshed.RefreshToken+`"}`, refreshed.AccessToken)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	_, err = RedisClient.ValidateToken(refreshed.AccessToken, user.AccessToken)
+	assert.NotNil(t, err)
+	_, err = RedisClient.ValidateToken(refreshed.RefreshToken, user.RefreshToken)
+	assert.NotNil(t, err)
+}
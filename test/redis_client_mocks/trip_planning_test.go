@@ -0,0 +1,84 @@
+package redis_client_mocks
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func postTrip(myPlanner planner.MyPlanner, req planner.TripPlanningRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	recorder := httptest.NewRecorder()
+	httpReq := httptest.NewRequest(http.MethodPost, "/v1/trips", strings.NewReader(string(body)))
+	httpReq.Header.Set("Content-Type", "application/json")
+	myPlanner.SetupRouter("10000").Handler.ServeHTTP(recorder, httpReq)
+	return recorder
+}
+
+func TestLongTripsArePlannedInJobs(t *testing.T) {
+	// the time zone of the destination is cached, so that start dates are checked without Maps APIs
+	redisURL, _ := url.Parse("redis://" + RedisMockSvr.Addr())
+	poiSearcher := &iowrappers.PoiSearcher{}
+	poiSearcher.Init("maps_api_key", redisURL, iowrappers.DefaultPoiSearcherConfig())
+	query := iowrappers.GeocodeQuery{City: "San Diego", Country: "USA"}
+	RedisClient.SetGeocode(query, 32.7157, -117.1611, query)
+	assert.Nil(t, RedisClient.SetTimeZone(query, "America/Los_Angeles"))
+
+	myPlanner := planner.MyPlanner{
+		RedisClient:  RedisClient,
+		PlanningJobs: make(chan planner.PlanningJob, 1),
+	}
+	myPlanner.Solver.Init(poiSearcher, solution.DefaultSolverConfig())
+	tripReq := planner.TripPlanningRequest{
+		StartDate: "2030-06-01",
+		NumDays:   planner.MaxSyncTripDays + 1,
+		DailyTemplate: planner.PlanningPostRequest{
+			Country:   "USA",
+			City:      "San Diego",
+			StartTime: POI.NewTimeOfDay(9, 0),
+			EndTime:   POI.NewTimeOfDay(18, 0),
+			NumVisit:  2,
+			NumEatery: 1,
+		},
+	}
+
+	recorder := postTrip(myPlanner, tripReq)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	created := planner.PlanJobCreatedResponse{}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	assert.Equal(t, iowrappers.PlanJobQueued, created.Status)
+
+	job := <-myPlanner.PlanningJobs
+	assert.Equal(t, created.JobID, job.ID)
+	assert.Equal(t, tripReq.NumDays, job.Trip.NumDays)
+
+	// invalid daily templates are rejected before the trip is queued
+	tripReq.DailyTemplate.EndTime = POI.NewTimeOfDay(8, 0)
+	recorder = postTrip(myPlanner, tripReq)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Empty(t, myPlanner.PlanningJobs)
+
+	tripReq.NumDays = planner.MaxTripDays + 1
+	recorder = postTrip(myPlanner, tripReq)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// trips starting in the past at the destination are rejected in the same way as single-day plans
+	tripReq.DailyTemplate.EndTime = POI.NewTimeOfDay(18, 0)
+	tripReq.NumDays = planner.MaxSyncTripDays + 1
+	tripReq.StartDate = "2020-06-01"
+	recorder = postTrip(myPlanner, tripReq)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "in the past")
+	assert.Empty(t, myPlanner.PlanningJobs)
+
+	tripResp := myPlanner.TripPlanning(&tripReq, planner.Requester{Username: planner.GuestUsername})
+	assert.Equal(t, uint(http.StatusBadRequest), tripResp.StatusCode)
+}
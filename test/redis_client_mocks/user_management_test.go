@@ -0,0 +1,128 @@
+package redis_client_mocks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/user"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUserManagement(t *testing.T) {
+	username := "margaret_hamilton"
+	if err := RedisClient.CreateUser(user.User{Username: username, Password: "apollo 11", Email: "margaret@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := RedisClient.CreateAPIKey(username, "guidance computer"); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := RedisClient.ListUsers()
+	assert.Nil(t, err)
+	var found bool
+	for _, u := range users {
+		if u.Username == username {
+			found = true
+			assert.Empty(t, u.Password)
+		}
+	}
+	assert.True(t, found)
+
+	assert.Nil(t, RedisClient.SetUserLevel(username, user.LevelAdmin))
+	u, _ := RedisClient.FindUser(username)
+	assert.Equal(t, user.LevelAdmin, u.UserLevel)
+	assert.NotNil(t, RedisClient.SetUserLevel(username, "Superuser"))
+
+	assert.Nil(t, RedisClient.SetUserDisabled(username, true))
+	assert.NotNil(t, RedisClient.CheckUserActive(username))
+	_, err = RedisClient.Authenticate(user.Credential{Username: username, Password: "apollo 11"})
+	assert.NotNil(t, err)
+	assert.Nil(t, RedisClient.SetUserDisabled(username, false))
+	assert.Nil(t, RedisClient.CheckUserActive(username))
+
+	assert.Nil(t, RedisClient.DeleteUser(username))
+	_, err = RedisClient.FindUser(username)
+	assert.NotNil(t, err)
+	assert.NotNil(t, RedisClient.CheckUserActive(username))
+	assert.False(t, RedisMockSvr.Exists("api_keys:user:"+username))
+	_, err = RedisClient.FindUserByEmail("margaret@example.com")
+	assert.NotNil(t, err)
+}
+
+func TestAdminUsersCannotBeRemoved(t *testing.T) {
+	_ = os.Setenv("ADMIN_USERS", "root_admin")
+	defer os.Unsetenv("ADMIN_USERS")
+
+	for _, u := range []user.User{
+		{Username: "root_admin", Password: "root password", UserLevel: user.LevelAdmin},
+		{Username: "second_admin", Password: "admin password", UserLevel: user.LevelAdmin},
+	} {
+		if err := RedisClient.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tokens, err := issueTokens("second_admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	myPlanner := planner.MyPlanner{RedisClient: RedisClient}
+	handler := myPlanner.SetupRouter("10000").Handler
+	send := func(method string, target string, body string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, "/v1/admin/users/root_admin", ""))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/v1/admin/users/root_admin/disable", ""))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPut, "/v1/admin/users/root_admin/level", `{"user_level": "Regular"}`))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, "/v1/admin/users/second_admin", ""))
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/v1/admin/users/root_admin", ""))
+	assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/v1/admin/users/nobody", ""))
+}
+
+func TestTokensOfDeletedUsers(t *testing.T) {
+	username := "katherine_johnson"
+	if err := RedisClient.CreateUser(user.User{Username: username, Password: "orbital mechanics"}); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := RedisClient.Authenticate(user.Credential{Username: username, Password: "orbital mechanics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resetToken, err := RedisClient.CreateUserToken(iowrappers.PasswordResetToken, username, iowrappers.PasswordResetTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verificationToken, err := RedisClient.CreateUserToken(iowrappers.EmailVerificationToken, username, iowrappers.EmailVerificationTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, RedisClient.DeleteUser(username))
+
+	// tokens of the deleted user are not valid for a new user with the same username
+	if err = RedisClient.CreateUser(user.User{Username: username, Password: "trajectory"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = RedisClient.ValidateToken(tokens.AccessToken, user.AccessToken)
+	assert.NotNil(t, err)
+	_, err = RedisClient.ValidateToken(tokens.RefreshToken, user.RefreshToken)
+	assert.NotNil(t, err)
+	_, err = RedisClient.ConsumeUserToken(iowrappers.PasswordResetToken, resetToken)
+	assert.NotNil(t, err)
+	_, err = RedisClient.ConsumeUserToken(iowrappers.EmailVerificationToken, verificationToken)
+	assert.NotNil(t, err)
+
+	tokens, err = RedisClient.Authenticate(user.Credential{Username: username, Password: "trajectory"})
+	assert.Nil(t, err)
+	_, err = RedisClient.ValidateToken(tokens.AccessToken, user.AccessToken)
+	assert.Nil(t, err)
+}
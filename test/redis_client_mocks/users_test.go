@@ -17,7 +17,7 @@ func TestUserAuthentication(t *testing.T) {
 	}
 
 	// authenticate the user
-	_, _, err := RedisClient.Authenticate(user.Credential{
+	_, err := RedisClient.Authenticate(user.Credential{
 		Username: username,
 		Password: password,
 	})
@@ -0,0 +1,140 @@
+package redis_client_mocks
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/iowrappers"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseWarmUpDestinations(t *testing.T) {
+	destinations, err := planner.ParseWarmUpDestinations([][]string{
+		{"USA", "New York City"},
+		{""},
+		{" USA ", "Boston"},
+		{"USA", "New York City"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []planner.WarmUpDestination{{Country: "USA", City: "New York City"}, {Country: "USA", City: "Boston"}}, destinations)
+	assert.Equal(t, "Boston,USA", destinations[1].String())
+
+	_, err = planner.ParseWarmUpDestinations([][]string{{"USA", "Boston"}, {"Portugal"}})
+	assert.EqualError(t, err, "row 2: expected country and city")
+}
+
+func TestWarmUpProgress(t *testing.T) {
+	defer RedisClient.ResetWarmUp()
+
+	completed, err := RedisClient.IsWarmUpCompleted("Boston,USA")
+	assert.Nil(t, err)
+	assert.False(t, completed)
+
+	assert.Nil(t, RedisClient.SetWarmUpCompleted("Boston,USA"))
+	completed, err = RedisClient.IsWarmUpCompleted("Boston,USA")
+	assert.Nil(t, err)
+	assert.True(t, completed)
+	// progress expires with the cached slot solutions
+	assert.Equal(t, iowrappers.SlotSolutionExpirationTime, RedisMockSvr.TTL(iowrappers.WarmUpCompletedRedisKey))
+
+	RedisMockSvr.FastForward(iowrappers.SlotSolutionExpirationTime)
+	completed, err = RedisClient.IsWarmUpCompleted("Boston,USA")
+	assert.Nil(t, err)
+	assert.False(t, completed)
+
+	assert.Nil(t, RedisClient.SetWarmUpCompleted("Boston,USA"))
+	assert.Nil(t, RedisClient.ResetWarmUp())
+	completed, err = RedisClient.IsWarmUpCompleted("Boston,USA")
+	assert.Nil(t, err)
+	assert.False(t, completed)
+}
+
+func TestCacheWarmerResumesRun(t *testing.T) {
+	defer RedisClient.ResetWarmUp()
+
+	destinations := []planner.WarmUpDestination{{Country: "USA", City: "New York City"}, {Country: "USA", City: "Boston"}}
+	for _, destination := range destinations {
+		assert.Nil(t, RedisClient.SetWarmUpCompleted(destination.String()))
+	}
+
+	// completed destinations are skipped without calling Maps APIs
+	warmer := planner.CacheWarmer{RedisClient: &RedisClient, Concurrency: 1}
+	skippedDestinations := make([]string, 0)
+	summary := warmer.Run(destinations, func(destination planner.WarmUpDestination, skipped bool, err error) {
+		assert.Nil(t, err)
+		if skipped {
+			skippedDestinations = append(skippedDestinations, destination.String())
+		}
+	})
+	assert.Equal(t, 2, summary.Total)
+	assert.Equal(t, 2, summary.Skipped)
+	assert.Equal(t, 0, summary.Completed)
+	assert.Equal(t, 0, summary.Failed)
+	assert.Empty(t, summary.Failures)
+	assert.ElementsMatch(t, []string{"New York City,USA", "Boston,USA"}, skippedDestinations)
+}
+
+// cache the geocode and places of both categories open all day near a destination
+// the places are recently searched and have details, so that the solver does not call Maps APIs
+func cacheDestinationPlaces(destination planner.WarmUpDestination, lat float64, lng float64, numPlaces int) {
+	query := iowrappers.GeocodeQuery{City: destination.City, Country: destination.Country}
+	RedisClient.SetGeocode(query, lat, lng, query)
+
+	places := make([]POI.Place, 0)
+	for idx := 0; idx < numPlaces; idx++ {
+		for _, locationType := range []POI.LocationType{POI.LocationTypeMuseum, POI.LocationTypeRestaurant} {
+			place := POI.Place{
+				ID:           fmt.Sprintf("%s_%s_%d", destination.City, locationType, idx),
+				Name:         fmt.Sprintf("%s %s %d", destination.City, locationType, idx),
+				LocationType: locationType,
+				Location:     POI.Location{Type: "point", Coordinates: [2]float64{lng + float64(idx)*0.001, lat}},
+				PriceLevel:   idx % 5,
+				Rating:       float32(3 + idx%3),
+			}
+			place.URL = "https://maps.google.com/?cid=" + place.ID
+			for day := range place.Hours {
+				place.Hours[day] = "Open 24 hours"
+			}
+			places = append(places, place)
+		}
+	}
+	RedisClient.SetPlacesOnCategory(places)
+	for _, placeCat := range []POI.PlaceCategory{POI.PlaceCategoryVisit, POI.PlaceCategoryEatery} {
+		_ = RedisClient.SetMapsLastSearchTime(destination.String(), placeCat, time.Now().Format(time.RFC3339))
+	}
+}
+
+// workers warm up destinations at the same time, run with -race to detect solvers shared by workers
+func TestCacheWarmerWarmsUpDestinationsConcurrently(t *testing.T) {
+	defer RedisClient.ResetWarmUp()
+
+	redisURL, _ := url.Parse("redis://" + RedisMockSvr.Addr())
+	poiSearcher := &iowrappers.PoiSearcher{}
+	poiSearcher.Init("maps_api_key", redisURL, iowrappers.DefaultPoiSearcherConfig())
+
+	destinations := []planner.WarmUpDestination{{Country: "Portugal", City: "Porto"}, {Country: "Portugal", City: "Coimbra"}}
+	cacheDestinationPlaces(destinations[0], 41.1579, -8.6291, 6)
+	cacheDestinationPlaces(destinations[1], 40.2033, -8.4103, 6)
+	solverConfig := solution.DefaultSolverConfig()
+	solverConfig.TimeClusterMinResults = 6
+
+	warmer := planner.CacheWarmer{
+		RedisClient:  &RedisClient,
+		PoiSearcher:  poiSearcher,
+		SolverConfig: solverConfig,
+		Concurrency:  2,
+		Weekdays:     []POI.Weekday{POI.DateSaturday, POI.DateSunday},
+	}
+	summary := warmer.Run(destinations, nil)
+	assert.Empty(t, summary.Failures)
+	assert.Equal(t, 2, summary.Completed)
+	for _, destination := range destinations {
+		completed, err := RedisClient.IsWarmUpCompleted(destination.String())
+		assert.Nil(t, err)
+		assert.True(t, completed)
+	}
+}
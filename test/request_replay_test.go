@@ -0,0 +1,132 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCaptureAndReadRequests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "captured_requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, planner.CapturedRequestsFile)
+
+	recorder, err := planner.NewRequestRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := &planner.PlanningPostRequest{
+		Country:   " USA ",
+		City:      "San \n Diego",
+		Date:      "2026-11-07",
+		StartTime: POI.NewTimeOfDay(9, 0),
+		EndTime:   POI.NewTimeOfDay(18, 0),
+		NumVisit:  2,
+		NumEatery: 1,
+	}
+	postReq, err := planner.ProcessPlanningPostRequest(post, planner.DefaultPlannerConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, _ := solution.SystemDayTemplate(solution.StandardTemplateName)
+	getReq := template.PlanningRequest(strings.Repeat("Lisbon", 50)+",Portugal", POI.DateSaturday, 5)
+	getReq.SearchRadius = 10000
+	recorder.Record(planner.NewCapturedRequest(&postReq))
+	recorder.Record(planner.NewCapturedRequest(&getReq))
+	assert.Nil(t, recorder.Close())
+	// requests of handlers are not modified
+	assert.Equal(t, "San \n Diego, USA ", postReq.SlotRequests[0].Location)
+
+	// a nil recorder does not capture requests
+	var disabledRecorder *planner.RequestRecorder
+	disabledRecorder.Record(planner.NewCapturedRequest(&postReq))
+	assert.Nil(t, disabledRecorder.Close())
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	requests, err := planner.ReadCapturedRequests(file)
+	assert.Nil(t, err)
+	assert.Len(t, requests, 2)
+
+	assert.False(t, requests[0].Time.IsZero())
+	assert.Equal(t, "San Diego,USA", requests[0].Slots[0].Location)
+	assert.Equal(t, "San Diego,USA 2026-11-07 09:00-18:00", requests[0].String())
+	assert.Len(t, []rune(strings.Split(requests[1].Slots[0].Location, ",")[0]), 100)
+	assert.Equal(t, requests[1].Slots[0].Location+" Saturday 09:00-20:00", requests[1].String())
+
+	planningReq, err := requests[0].PlanningRequest()
+	assert.Nil(t, err)
+	assert.Equal(t, POI.DateSaturday, planningReq.Weekday)
+	assert.Equal(t, postReq.SlotRequests[0].StayTimes, planningReq.SlotRequests[0].StayTimes)
+	assert.Equal(t, len(postReq.SlotRequests), len(planningReq.SlotRequests))
+
+	planningReq, err = requests[1].PlanningRequest()
+	assert.Nil(t, err)
+	assert.Equal(t, uint(10000), planningReq.SearchRadius)
+	assert.Equal(t, uint64(5), planningReq.NumResults)
+	assert.Equal(t, len(getReq.SlotRequests), len(planningReq.SlotRequests))
+
+	_, err = planner.CapturedRequest{}.PlanningRequest()
+	assert.Error(t, err)
+
+	_, err = planner.ReadCapturedRequests(strings.NewReader("{\"slots\":[]}\n\nnot json\n"))
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "line 3:"))
+}
+
+func replayReportFixture(results ...planner.ReplayResult) planner.ReplayReport {
+	return planner.ReplayReport{NumRequests: len(results), Results: results}
+}
+
+func TestDiffReplayReports(t *testing.T) {
+	baseline := replayReportFixture(
+		planner.ReplayResult{Request: "a", NumSolutions: 2, Scores: []float64{10, 8}},
+		planner.ReplayResult{Request: "b", NumSolutions: 2, Scores: []float64{10, 8}},
+		planner.ReplayResult{Request: "c", NumSolutions: 2, Scores: []float64{10, 8}},
+		planner.ReplayResult{Request: "d", NumSolutions: 2, Scores: []float64{10, 8}},
+		planner.ReplayResult{Request: "e", Error: "invalid travel destination", Scores: []float64{}},
+		planner.ReplayResult{Request: "f", NumSolutions: 1, Scores: []float64{10}},
+	)
+	current := replayReportFixture(
+		planner.ReplayResult{Request: "a", NumSolutions: 2, Scores: []float64{10, 8}},
+		planner.ReplayResult{Request: "b", NumSolutions: 2, Scores: []float64{9.5, 8}},
+		planner.ReplayResult{Request: "c", NumSolutions: 1, Scores: []float64{12}},
+		planner.ReplayResult{Request: "d", Error: "travel time limit exceeded for current selection", Scores: []float64{}},
+		planner.ReplayResult{Request: "e", NumSolutions: 1, Scores: []float64{5}},
+		planner.ReplayResult{Request: "f", NumSolutions: 1, Scores: []float64{11}},
+	)
+
+	diff, err := planner.DiffReplayReports(baseline, current, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, diff.NumUnchanged)
+	assert.Equal(t, 2, diff.NumImproved)
+	assert.Equal(t, []planner.ReplayRegression{
+		{Request: "b", Reason: "top score 9.5000, baseline top score 10.0000"},
+		{Request: "c", Reason: "1 solutions, baseline has 2 solutions"},
+		{Request: "d", Reason: "error: travel time limit exceeded for current selection"},
+	}, diff.Regressions)
+
+	// score drops within the tolerance are not regressions
+	diff, err = planner.DiffReplayReports(baseline, current, 0.5)
+	assert.Nil(t, err)
+	assert.Len(t, diff.Regressions, 2)
+
+	// baselines of other captured requests cannot be compared
+	_, err = planner.DiffReplayReports(baseline, replayReportFixture(current.Results[:5]...), 0)
+	assert.Error(t, err)
+	current.Results[0].Request = "z"
+	_, err = planner.DiffReplayReports(baseline, current, 0)
+	assert.Error(t, err)
+}
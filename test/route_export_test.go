@@ -0,0 +1,74 @@
+package test
+
+import (
+	"encoding/xml"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/matching"
+	"github.com/weihesdlegend/Vacation-planner/solution"
+	"testing"
+)
+
+func routeExportFixture() (solution.MultiSlotSolution, []solution.SlotRequest) {
+	timeSlot := func(start, end POI.TimeOfDay) matching.TimeSlot {
+		slot := matching.TimeSlot{}
+		slot.Slot.Start = start
+		slot.Slot.End = end
+		return slot
+	}
+	multiSlotSolution := solution.MultiSlotSolution{
+		SlotSolutions: []solution.SlotSolutionCandidate{{
+			PlaceNames:     []string{"Tartine Bakery", "Golden Gate Park"},
+			PlaceLocations: [][2]float64{{-122.424, 37.761}, {-122.486, 37.769}},
+			PlaceAddresses: []string{"600 Guerrero St", "San Francisco, CA"},
+			PlaceURLs:      []string{"https://maps.google.com/?cid=1", ""},
+			IsSet:          true,
+		}},
+	}
+	slotRequests := []solution.SlotRequest{{
+		Location:  "San Francisco,USA",
+		EvOption:  "EV",
+		StayTimes: []matching.TimeSlot{timeSlot(POI.NewTimeOfDay(9, 0), POI.NewTimeOfDay(10, 0)), timeSlot(POI.NewTimeOfDay(10, 0), POI.NewTimeOfDay(12, 0))},
+	}}
+	return multiSlotSolution, slotRequests
+}
+
+func TestToGeoJSON(t *testing.T) {
+	collection := solution.ToGeoJSON(routeExportFixture())
+	if collection.Type != "FeatureCollection" {
+		t.Fatalf("expected a FeatureCollection, got %s", collection.Type)
+	}
+	// two places and the route connecting them
+	if len(collection.Features) != 3 {
+		t.Fatalf("expected 3 features, got %d", len(collection.Features))
+	}
+
+	firstPlace := collection.Features[0]
+	if coordinates := firstPlace.Geometry.Coordinates.([2]float64); coordinates[0] != -122.424 || coordinates[1] != 37.761 {
+		t.Errorf("expected coordinates in longitude, latitude order, got %v", coordinates)
+	}
+	if firstPlace.Properties["category"] != POI.PlaceCategoryEatery || firstPlace.Properties["start_time"] != "09:00" {
+		t.Errorf("unexpected properties %v", firstPlace.Properties)
+	}
+	if route := collection.Features[2]; route.Geometry.Type != "LineString" {
+		t.Errorf("expected the last feature to be a LineString, got %s", route.Geometry.Type)
+	}
+}
+
+func TestToGPX(t *testing.T) {
+	multiSlotSolution, slotRequests := routeExportFixture()
+	data, err := solution.ToGPX(multiSlotSolution, slotRequests, "San Francisco")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gpx := solution.GPX{}
+	if err = xml.Unmarshal(data, &gpx); err != nil {
+		t.Fatal(err)
+	}
+	if len(gpx.Waypoints) != 2 || len(gpx.Route.Points) != 2 {
+		t.Fatalf("expected 2 waypoints and 2 route points, got %d and %d", len(gpx.Waypoints), len(gpx.Route.Points))
+	}
+	if waypoint := gpx.Waypoints[1]; waypoint.Lat != 37.769 || waypoint.Lon != -122.486 || waypoint.Link != nil {
+		t.Errorf("unexpected waypoint %+v", waypoint)
+	}
+}
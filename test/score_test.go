@@ -14,7 +14,7 @@ func TestScoreFunction(t *testing.T) {
 	}, POI.PlaceCategoryVisit)
 
 	expectedScore := 1.0
-	score := matching.Score([]matching.Place{place1})
+	score := matching.Score([]matching.Place{place1}, matching.DefaultPriceLevels())
 	if score != expectedScore {
 		t.Errorf("Expected score %f, got %f", expectedScore, score)
 	}
@@ -26,14 +26,14 @@ func TestScoreFunction(t *testing.T) {
 	}, POI.PlaceCategoryVisit)
 
 	expectedScore = 0.1
-	score = matching.Score([]matching.Place{place2})
+	score = matching.Score([]matching.Place{place2}, matching.DefaultPriceLevels())
 	if score != expectedScore {
 		t.Errorf("Expected score %f, got %f", expectedScore, score)
 	}
 
 	// test multiple places with same location (NYC)
 	places := []matching.Place{place1, place2}
-	score = matching.Score(places)
+	score = matching.Score(places, matching.DefaultPriceLevels())
 	expectedScore = 0.55
 	if score != expectedScore {
 		t.Errorf("Expected score %f, got %f", expectedScore, score)
@@ -13,7 +13,7 @@ func TestSolutionCandidateSelection(t *testing.T) {
 		candidates = append(candidates, solution.SlotSolutionCandidate{Score: float64(i)})
 	}
 
-	res := solution.FindBestCandidates(candidates)
+	res := solution.FindBestCandidates(candidates, solution.CandidateQueueLength)
 
 	expectation := []float64{85, 86, 87, 88, 89, 90, 91, 92, 93, 94, 95, 96, 97, 98, 99}
 	for idx, r := range res {
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressStreamsOutliveWriteTimeout(t *testing.T) {
+	const numEvents = 6
+	streamEvents := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for idx := 0; idx < numEvents; idx++ {
+			fmt.Fprintf(w, "data: %d\n\n", idx)
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(planner.ProgressPath, streamEvents)
+	mux.HandleFunc("/v1/plans", streamEvents)
+
+	server := httptest.NewUnstartedServer(planner.StreamingHandler(mux))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + planner.ProgressPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, numEvents, strings.Count(string(body), "data: "))
+
+	// other routes keep the write timeout
+	resp, err = http.Get(server.URL + "/v1/plans")
+	if err == nil {
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	assert.True(t, err != nil || strings.Count(string(body), "data: ") < numEvents)
+}
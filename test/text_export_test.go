@@ -0,0 +1,49 @@
+package test
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"strings"
+	"testing"
+)
+
+func textExportFixture() planner.PlanningResponse {
+	return planner.PlanningResponse{
+		TravelDestination: "San Diego",
+		Date:              "2026-11-07",
+		Places: [][]planner.TimeSectionPlaces{{{
+			Places: []planner.TimeSectionPlace{
+				{PlaceName: "Hodad's", Category: POI.PlaceCategoryEatery, StartTime: POI.NewTimeOfDay(9, 0), EndTime: POI.NewTimeOfDay(10, 0),
+					Address: "5010 Newport Ave", URL: "https://maps.google.com/?cid=1"},
+				{PlaceName: "Balboa Park | Museums", Category: POI.PlaceCategoryVisit, StartTime: POI.NewTimeOfDay(10, 0), EndTime: POI.NewTimeOfDay(14, 30),
+					Address: "1549 El Prado"},
+			},
+		}}},
+	}
+}
+
+func TestWritePlanningTable(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.Nil(t, planner.WritePlanningText(buffer, planner.TextFormatTable, textExportFixture()))
+
+	lines := strings.Split(buffer.String(), "\n")
+	assert.Equal(t, "San Diego, 2026-11-07 (Saturday)", lines[0])
+	assert.Equal(t, "Plan 1", lines[2])
+	assert.Regexp(t, `^START\s+END\s+CATEGORY\s+PLACE\s+ADDRESS$`, lines[4])
+	assert.Regexp(t, `^10:00\s+14:30\s+Visit\s+Balboa Park \| Museums\s+1549 El Prado$`, lines[6])
+}
+
+func TestWritePlanningMarkdown(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.Nil(t, planner.WritePlanningText(buffer, planner.TextFormatMarkdown, textExportFixture()))
+
+	output := buffer.String()
+	assert.True(t, strings.HasPrefix(output, "# San Diego, 2026-11-07 (Saturday)\n"))
+	assert.Contains(t, output, "## Plan 1")
+	assert.Contains(t, output, "| Start | End | Category | Place | Address |\n| --- | --- | --- | --- | --- |")
+	assert.Contains(t, output, "| 09:00 | 10:00 | Eatery | [Hodad's](https://maps.google.com/?cid=1) | 5010 Newport Ave |")
+	// pipes in cells are escaped
+	assert.Contains(t, output, `| Balboa Park \| Museums |`)
+}
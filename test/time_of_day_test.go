@@ -0,0 +1,62 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"testing"
+)
+
+func TestParseTimeInterval(t *testing.T) {
+	expected := map[string]POI.TimeInterval{
+		"Monday: 10:45 AM – 5:30 PM":                 {Start: POI.NewTimeOfDay(10, 45), End: POI.NewTimeOfDay(17, 30)},
+		"Tuesday: 12:00 PM – 12:30 AM":               {Start: POI.NewTimeOfDay(12, 0), End: POI.EndOfDay},
+		"Wednesday: 12:15 AM – 11:00 AM":             {Start: POI.NewTimeOfDay(0, 15), End: POI.NewTimeOfDay(11, 0)},
+		"Thursday: 5:00 – 10:00 PM":                  {Start: POI.NewTimeOfDay(17, 0), End: POI.NewTimeOfDay(22, 0)},
+		"Friday: 11:30 AM – 2:30 PM, 5:00 – 9:00 PM": {Start: POI.NewTimeOfDay(11, 30), End: POI.NewTimeOfDay(14, 30)},
+		"Saturday: Open 24 hours":                    {Start: 0, End: POI.EndOfDay},
+		"Sunday: Closed":                             {},
+	}
+	for openingHour, expectedInterval := range expected {
+		interval, err := POI.ParseTimeInterval(openingHour)
+		if err != nil {
+			t.Errorf("failed to parse %s: %s", openingHour, err.Error())
+			continue
+		}
+		if interval != expectedInterval {
+			t.Errorf("wrong interval for %s. expected: %s-%s, got: %s-%s", openingHour,
+				expectedInterval.Start, expectedInterval.End, interval.Start, interval.End)
+		}
+	}
+
+	if _, err := POI.ParseTimeInterval("Monday: 10:45 AM"); err == nil {
+		t.Error("expected an error for opening hours without closing time")
+	}
+}
+
+func TestTimeOfDayJSON(t *testing.T) {
+	var times struct {
+		StartTime POI.TimeOfDay `json:"start_time"`
+		EndTime   POI.TimeOfDay `json:"end_time"`
+	}
+	// whole hours in integers are still accepted
+	if err := json.Unmarshal([]byte(`{"start_time": 9, "end_time": "12:15"}`), &times); err != nil {
+		t.Fatal(err)
+	}
+	if times.StartTime != POI.NewTimeOfDay(9, 0) || times.EndTime != POI.NewTimeOfDay(12, 15) {
+		t.Errorf("unexpected times %s and %s", times.StartTime, times.EndTime)
+	}
+
+	data, err := json.Marshal(times)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"start_time":"09:00","end_time":"12:15"}` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	for _, invalidTime := range []string{`"9:60"`, `"25:00"`, `"noon"`, `25`} {
+		if err = json.Unmarshal([]byte(`{"start_time": `+invalidTime+`}`), &times); err == nil {
+			t.Errorf("expected an error for time of day %s", invalidTime)
+		}
+	}
+}
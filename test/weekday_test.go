@@ -0,0 +1,38 @@
+package test
+
+import (
+	"github.com/weihesdlegend/Vacation-planner/POI"
+	"github.com/weihesdlegend/Vacation-planner/planner"
+	"testing"
+	"time"
+)
+
+func TestGetWeekday(t *testing.T) {
+	expected := []POI.Weekday{POI.DateThursday, POI.DateFriday, POI.DateSaturday, POI.DateSunday, POI.DateMonday}
+	startDate := time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC) // a Thursday
+	for idx, expectedWeekday := range expected {
+		date := startDate.AddDate(0, 0, idx)
+		if weekday := POI.GetWeekday(date); weekday != expectedWeekday {
+			t.Errorf("wrong weekday for %s. expected: %d, got: %d", date.Format("2006-01-02"), expectedWeekday, weekday)
+		}
+	}
+}
+
+func TestValidatePlanningDate(t *testing.T) {
+	// 2020-10-02 at 02:00 in UTC is still 2020-10-01 in Los Angeles
+	now := time.Date(2020, time.October, 2, 2, 0, 0, 0, time.UTC)
+	timeZone := "America/Los_Angeles"
+
+	if err := planner.ValidatePlanningDate("2020-10-01", timeZone, now); err != nil {
+		t.Errorf("expected today at the destination to be valid, got error: %s", err.Error())
+	}
+	if err := planner.ValidatePlanningDate("2020-09-30", timeZone, now); err == nil {
+		t.Error("expected past date at the destination to be invalid")
+	}
+	if err := planner.ValidatePlanningDate("2020-10-01", "Asia/Tokyo", now); err == nil {
+		t.Error("expected past date at the destination to be invalid")
+	}
+	if err := planner.ValidatePlanningDate("10/01/2020", timeZone, now); err == nil {
+		t.Error("expected date not in the format of YYYY-MM-DD to be invalid")
+	}
+}
@@ -0,0 +1,44 @@
+package user
+
+import (
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"time"
+)
+
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+
+	AccessTokenExpirationTime  = time.Hour
+	RefreshTokenExpirationTime = time.Hour * 240 // 10 days
+)
+
+// claims of the JWTs issued to users, identity is only derived from validated claims
+type Claims struct {
+	Username   string `json:"username"`
+	TokenType  string `json:"token_type"`
+	Generation int64  `json:"generation"` // tokens of earlier generations of the user are invalid
+	jwt.StandardClaims
+}
+
+func (claims Claims) Valid() error {
+	if claims.ExpiresAt == 0 {
+		return errors.New("token has no expiration time")
+	}
+	if claims.Id == "" {
+		return errors.New("token has no ID")
+	}
+	if claims.Username == "" || claims.Subject != claims.Username {
+		return errors.New("token has no valid username")
+	}
+	return claims.StandardClaims.Valid()
+}
+
+// an access token and the refresh token for getting new access tokens
+type TokenPair struct {
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
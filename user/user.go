@@ -1,20 +1,17 @@
 package user
 
-import (
-	"time"
-)
-
 const (
-	LevelAdmin        = "Admin"
-	LevelRegular      = "Regular"
-	JWTExpirationTime = time.Hour * 240 // 10 days
+	LevelAdmin   = "Admin"
+	LevelRegular = "Regular"
 )
 
 type User struct {
-	Username      string    `json:"username"`
-	Password      string    `json:"password"`
-	Email         string    `json:"email"`
-	UserLevel     string    `json:"user_level"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	Email         string `json:"email"`
+	UserLevel     string `json:"user_level"`
+	EmailVerified bool   `json:"email_verified"`
+	Disabled      bool   `json:"disabled"`
 }
 
 type Credential struct {